@@ -0,0 +1,279 @@
+package authz
+
+import (
+	"context"
+	"errors"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shopastro/go-common/controller"
+	"github.com/shopastro/logs"
+	"go.uber.org/zap"
+)
+
+type (
+	// Policy applies to the routes matching Method and Path (a gin route
+	// pattern or a path.Match glob) and to the gRPC methods matching
+	// GrpcMethod. A subject needs any of Roles, all of Permissions and, when
+	// Owner is set, the owner check of that name to pass.
+	Policy struct {
+		Name        string   `json:"name" yaml:"name"`
+		Method      string   `json:"method" yaml:"method"`
+		Path        string   `json:"path" yaml:"path"`
+		GrpcMethod  string   `json:"grpcMethod" yaml:"grpcMethod"`
+		Public      bool     `json:"public" yaml:"public"`
+		AllowGuest  bool     `json:"allowGuest" yaml:"allowGuest"`
+		Roles       []string `json:"roles" yaml:"roles"`
+		Permissions []string `json:"permissions" yaml:"permissions"`
+		Owner       string   `json:"owner" yaml:"owner"`
+	}
+
+	// PolicySet maps roles to permissions, "*" grants every permission.
+	PolicySet struct {
+		Roles    map[string][]string `json:"roles" yaml:"roles"`
+		Policies []*Policy           `json:"policies" yaml:"policies"`
+		// DefaultDeny rejects requests no policy matches.
+		DefaultDeny bool `json:"defaultDeny" yaml:"defaultDeny"`
+	}
+
+	Subject struct {
+		User  controller.UserInfoHeader
+		Roles []string
+	}
+
+	// Resource is what is being accessed, Params are the route params of a
+	// gin request and Message the request of a gRPC call.
+	Resource struct {
+		Method     string
+		Path       string
+		GrpcMethod string
+		Params     map[string]string
+		Message    interface{}
+	}
+
+	OwnerFunc func(ctx context.Context, subject *Subject, resource *Resource) (bool, error)
+
+	Decision struct {
+		Time     time.Time
+		Policy   string
+		Subject  *Subject
+		Resource *Resource
+		Allowed  bool
+		// Unauthenticated is set when the subject is a guest and the policy
+		// asks for a user.
+		Unauthenticated bool
+		Reason          string
+	}
+
+	DecisionLogger func(d *Decision)
+
+	Authorizer struct {
+		mu     sync.RWMutex
+		set    *PolicySet
+		owners map[string]OwnerFunc
+		log    DecisionLogger
+	}
+
+	Option func(*Authorizer)
+)
+
+var (
+	ErrUnauthenticated = errors.New("authz: login required")
+	ErrForbidden       = errors.New("authz: permission denied")
+)
+
+func NewAuthorizer(set *PolicySet, opts ...Option) *Authorizer {
+	a := &Authorizer{
+		set:    set,
+		owners: make(map[string]OwnerFunc),
+		log:    LogDecision,
+	}
+
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	return a
+}
+
+func WithOwner(name string, fn OwnerFunc) Option {
+	return func(a *Authorizer) {
+		a.owners[name] = fn
+	}
+}
+
+func WithDecisionLogger(log DecisionLogger) Option {
+	return func(a *Authorizer) {
+		a.log = log
+	}
+}
+
+// LogDecision is the default decision log.
+func LogDecision(d *Decision) {
+	logs.Logger.Info("[Authz decision]",
+		zap.String("policy", d.Policy),
+		zap.Bool("allowed", d.Allowed),
+		zap.String("reason", d.Reason),
+		zap.Uint64("userId", d.Subject.User.UserId),
+		zap.String("uuid", d.Subject.User.Uuid),
+		zap.Strings("roles", d.Subject.Roles),
+		zap.String("method", d.Resource.Method),
+		zap.String("path", d.Resource.Path),
+		zap.String("grpcMethod", d.Resource.GrpcMethod))
+}
+
+// Reload swaps the policies, e.g. after LoadFile.
+func (a *Authorizer) Reload(set *PolicySet) {
+	a.mu.Lock()
+	a.set = set
+	a.mu.Unlock()
+}
+
+// Match returns the first policy for resource, nil if there is none.
+func (a *Authorizer) Match(resource *Resource) *Policy {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	for _, p := range a.set.Policies {
+		if p.matches(resource) {
+			return p
+		}
+	}
+
+	return nil
+}
+
+// Authorize decides with the policy matching resource.
+func (a *Authorizer) Authorize(ctx context.Context, subject *Subject, resource *Resource) *Decision {
+	return a.Check(ctx, a.Match(resource), subject, resource)
+}
+
+// Check decides with p, which does not have to be part of the policy set.
+func (a *Authorizer) Check(ctx context.Context, p *Policy, subject *Subject, resource *Resource) *Decision {
+	d := a.decide(ctx, p, subject, resource)
+	if a.log != nil {
+		a.log(d)
+	}
+
+	return d
+}
+
+func (a *Authorizer) decide(ctx context.Context, p *Policy, subject *Subject, resource *Resource) *Decision {
+	d := &Decision{Time: time.Now(), Subject: subject, Resource: resource}
+
+	a.mu.RLock()
+	defaultDeny, roles := a.set.DefaultDeny, a.set.Roles
+	a.mu.RUnlock()
+
+	if p == nil {
+		d.Allowed, d.Reason = !defaultDeny, "no policy"
+		return d
+	}
+
+	d.Policy = p.Name
+	if p.Public {
+		d.Allowed, d.Reason = true, "public"
+		return d
+	}
+
+	if subject.User.IsGuest && !p.AllowGuest {
+		d.Unauthenticated, d.Reason = true, "guest"
+		return d
+	}
+
+	if len(p.Roles) > 0 && !hasAny(subject.Roles, p.Roles) {
+		d.Reason = "role"
+		return d
+	}
+
+	granted := permissions(roles, subject.Roles)
+	for _, perm := range p.Permissions {
+		if !granted["*"] && !granted[perm] {
+			d.Reason = "permission " + perm
+			return d
+		}
+	}
+
+	if p.Owner != "" {
+		a.mu.RLock()
+		owner, ok := a.owners[p.Owner]
+		a.mu.RUnlock()
+
+		if !ok {
+			d.Reason = "owner check " + p.Owner + " not registered"
+			return d
+		}
+
+		allowed, err := owner(ctx, subject, resource)
+		if err != nil {
+			d.Reason = "owner check " + p.Owner + ": " + err.Error()
+			return d
+		}
+
+		if !allowed {
+			d.Reason = "not owner"
+			return d
+		}
+	}
+
+	d.Allowed, d.Reason = true, "granted"
+	return d
+}
+
+// Err is nil for an allowed decision, else ErrUnauthenticated or ErrForbidden.
+func (d *Decision) Err() error {
+	switch {
+	case d.Allowed:
+		return nil
+	case d.Unauthenticated:
+		return ErrUnauthenticated
+	default:
+		return ErrForbidden
+	}
+}
+
+func (p *Policy) matches(r *Resource) bool {
+	if r.GrpcMethod != "" {
+		return p.GrpcMethod != "" && glob(p.GrpcMethod, r.GrpcMethod)
+	}
+
+	if p.Path == "" || (p.Method != "" && p.Method != "*" && !strings.EqualFold(p.Method, r.Method)) {
+		return false
+	}
+
+	return glob(p.Path, r.Path)
+}
+
+func glob(pattern, name string) bool {
+	if pattern == name {
+		return true
+	}
+
+	ok, _ := path.Match(pattern, name)
+	return ok
+}
+
+func hasAny(have, want []string) bool {
+	for _, w := range want {
+		for _, h := range have {
+			if h == w {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func permissions(roles map[string][]string, subjectRoles []string) map[string]bool {
+	granted := make(map[string]bool)
+	for _, r := range subjectRoles {
+		for _, perm := range roles[r] {
+			granted[perm] = true
+		}
+	}
+
+	return granted
+}
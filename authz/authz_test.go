@@ -0,0 +1,133 @@
+package authz
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/shopastro/go-common/controller"
+)
+
+func TestMatch(t *testing.T) {
+	a := NewAuthorizer(&PolicySet{Policies: []*Policy{
+		{Name: "get-article", Method: "GET", Path: "/api/articles/:id"},
+		{Name: "any-admin", Method: "*", Path: "/admin/*"},
+		{Name: "grpc-article", GrpcMethod: "/article.ArticleService/*"},
+		{Name: "all-users", Path: "/api/users/:id"},
+	}})
+
+	tests := []struct {
+		name     string
+		resource *Resource
+		want     string
+	}{
+		{"route pattern", &Resource{Method: "GET", Path: "/api/articles/:id"}, "get-article"},
+		{"method case", &Resource{Method: "get", Path: "/api/articles/:id"}, "get-article"},
+		{"other method", &Resource{Method: "PUT", Path: "/api/articles/:id"}, ""},
+		{"glob", &Resource{Method: "DELETE", Path: "/admin/routes"}, "any-admin"},
+		{"glob one segment", &Resource{Method: "GET", Path: "/admin/a/b"}, ""},
+		{"empty method matches all", &Resource{Method: "POST", Path: "/api/users/:id"}, "all-users"},
+		{"grpc", &Resource{GrpcMethod: "/article.ArticleService/Get"}, "grpc-article"},
+		{"grpc ignores routes", &Resource{GrpcMethod: "/admin/routes"}, ""},
+		{"no match", &Resource{Method: "GET", Path: "/health"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ""
+			if p := a.Match(tt.resource); p != nil {
+				got = p.Name
+			}
+
+			if got != tt.want {
+				t.Fatalf("Match() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	user := controller.UserInfoHeader{UserId: 7}
+	guest := controller.UserInfoHeader{IsGuest: true}
+	owns := func(ctx context.Context, s *Subject, r *Resource) (bool, error) {
+		return r.Params["userId"] == "7" && s.User.UserId == 7, nil
+	}
+	broken := func(context.Context, *Subject, *Resource) (bool, error) {
+		return false, errors.New("db down")
+	}
+
+	roles := map[string][]string{
+		"editor": {"article:read", "article:write"},
+		"admin":  {"*"},
+	}
+
+	tests := []struct {
+		name        string
+		defaultDeny bool
+		policy      *Policy
+		subject     *Subject
+		params      map[string]string
+		want        error
+	}{
+		{"no policy allows", false, nil, &Subject{User: guest}, nil, nil},
+		{"no policy default deny", true, nil, &Subject{User: user}, nil, ErrForbidden},
+		{"public", false, &Policy{Public: true, Roles: []string{"admin"}}, &Subject{User: guest}, nil, nil},
+		{"guest needs login", false, &Policy{}, &Subject{User: guest}, nil, ErrUnauthenticated},
+		{"guest allowed", false, &Policy{AllowGuest: true}, &Subject{User: guest}, nil, nil},
+		{"guest on owner policy", false, &Policy{Owner: "self"}, &Subject{User: guest}, map[string]string{"userId": "0"}, ErrUnauthenticated},
+		{"user", false, &Policy{}, &Subject{User: user}, nil, nil},
+		{"missing role", false, &Policy{Roles: []string{"admin", "editor"}}, &Subject{User: user, Roles: []string{"viewer"}}, nil, ErrForbidden},
+		{"any role", false, &Policy{Roles: []string{"admin", "editor"}}, &Subject{User: user, Roles: []string{"editor"}}, nil, nil},
+		{"permission granted", false, &Policy{Permissions: []string{"article:write"}}, &Subject{User: user, Roles: []string{"editor"}}, nil, nil},
+		{"permission missing", false, &Policy{Permissions: []string{"article:delete"}}, &Subject{User: user, Roles: []string{"editor"}}, nil, ErrForbidden},
+		{"wildcard permission", false, &Policy{Permissions: []string{"article:delete"}}, &Subject{User: user, Roles: []string{"admin"}}, nil, nil},
+		{"owner", false, &Policy{Owner: "self"}, &Subject{User: user}, map[string]string{"userId": "7"}, nil},
+		{"not owner", false, &Policy{Owner: "self"}, &Subject{User: user}, map[string]string{"userId": "8"}, ErrForbidden},
+		{"owner check fails", false, &Policy{Owner: "broken"}, &Subject{User: user}, nil, ErrForbidden},
+		{"owner check missing", false, &Policy{Owner: "unknown"}, &Subject{User: user}, nil, ErrForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logged *Decision
+			a := NewAuthorizer(&PolicySet{Roles: roles, DefaultDeny: tt.defaultDeny},
+				WithOwner("self", owns),
+				WithOwner("broken", broken),
+				WithDecisionLogger(func(d *Decision) { logged = d }),
+			)
+
+			d := a.Check(context.Background(), tt.policy, tt.subject, &Resource{Params: tt.params})
+			if err := d.Err(); err != tt.want {
+				t.Fatalf("Check() = %v (%s), want %v", err, d.Reason, tt.want)
+			}
+
+			if logged != d {
+				t.Fatal("decision was not logged")
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	set, err := Parse([]byte(`
+roles:
+  editor: [article:write]
+policies:
+  - name: update-article
+    method: PUT
+    path: /api/articles/:id
+    permissions: [article:write]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := NewAuthorizer(set, WithDecisionLogger(nil))
+	d := a.Authorize(context.Background(),
+		&Subject{User: controller.UserInfoHeader{UserId: 1}, Roles: []string{"editor"}},
+		&Resource{Method: "PUT", Path: "/api/articles/:id"})
+
+	if !d.Allowed || d.Policy != "update-article" {
+		t.Fatalf("Authorize() = %+v", d)
+	}
+}
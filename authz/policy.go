@@ -0,0 +1,41 @@
+package authz
+
+import (
+	"io/ioutil"
+
+	"gopkg.in/yaml.v2"
+)
+
+// LoadFile reads a policy set from YAML:
+//
+//	roles:
+//	  editor: [article:read, article:write]
+//	  admin: ["*"]
+//	policies:
+//	  - name: update-article
+//	    method: PUT
+//	    path: /api/articles/:id
+//	    grpcMethod: /article.ArticleService/Update
+//	    permissions: [article:write]
+//	    owner: article
+func LoadFile(file string) (*PolicySet, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	return Parse(data)
+}
+
+func Parse(data []byte) (*PolicySet, error) {
+	set := &PolicySet{}
+	if err := yaml.Unmarshal(data, set); err != nil {
+		return nil, err
+	}
+
+	if set.Roles == nil {
+		set.Roles = make(map[string][]string)
+	}
+
+	return set, nil
+}
@@ -0,0 +1,413 @@
+package client
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/shopastro/logs"
+	"go.uber.org/zap"
+)
+
+type (
+	// BalancerConfig durations are in milliseconds. A host is ejected after
+	// MaxFailures consecutive failures or when its failure rate within Window
+	// reaches ErrorRate; each further ejection doubles EjectionTime up to
+	// MaxEjectionTime. The remote breaker opens on BreakerErrorRate.
+	BalancerConfig struct {
+		Strategy           string         `json:"strategy" yaml:"strategy"`
+		Weights            map[string]int `json:"weights" yaml:"weights"`
+		MaxFailures        int            `json:"maxFailures" yaml:"maxFailures"`
+		ErrorRate          float64        `json:"errorRate" yaml:"errorRate"`
+		MinRequests        int            `json:"minRequests" yaml:"minRequests"`
+		Window             time.Duration  `json:"window" yaml:"window"`
+		SlowThreshold      time.Duration  `json:"slowThreshold" yaml:"slowThreshold"`
+		EjectionTime       time.Duration  `json:"ejectionTime" yaml:"ejectionTime"`
+		MaxEjectionTime    time.Duration  `json:"maxEjectionTime" yaml:"maxEjectionTime"`
+		BreakerErrorRate   float64        `json:"breakerErrorRate" yaml:"breakerErrorRate"`
+		BreakerMinRequests int            `json:"breakerMinRequests" yaml:"breakerMinRequests"`
+		BreakerOpenTimeout time.Duration  `json:"breakerOpenTimeout" yaml:"breakerOpenTimeout"`
+	}
+
+	HostStat struct {
+		Host         string        `json:"host"`
+		Inflight     int64         `json:"inflight"`
+		Requests     int           `json:"requests"`
+		Failures     int           `json:"failures"`
+		Latency      time.Duration `json:"latency"`
+		Ejections    int           `json:"ejections"`
+		EjectedUntil time.Time     `json:"ejectedUntil"`
+	}
+
+	host struct {
+		addr         string
+		weight       int
+		inflight     int64
+		consecutive  int
+		requests     int
+		failures     int
+		windowStart  time.Time
+		latency      time.Duration
+		ejections    int
+		ejectedUntil time.Time
+	}
+
+	remotePool struct {
+		mu    sync.Mutex
+		name  string
+		cfg   *BalancerConfig
+		hosts []*host
+		next  uint64
+
+		state       int
+		openedAt    time.Time
+		probing     bool
+		probe       *host
+		windowStart time.Time
+		requests    int
+		failures    int
+	}
+)
+
+const (
+	StrategyRandom        = "random"
+	StrategyRoundRobin    = "round_robin"
+	StrategyLeastRequests = "least_requests"
+	StrategyWeighted      = "weighted"
+
+	latencyDecay = 0.3
+)
+
+const (
+	poolClosed = iota
+	poolOpen
+	poolHalfOpen
+)
+
+var (
+	ErrCircuitOpen = errors.New("circuit breaker is open")
+
+	pools   = make(map[string]*remotePool)
+	poolsMu sync.Mutex
+
+	balancerDefault = BalancerConfig{
+		Strategy:           StrategyRandom,
+		MaxFailures:        5,
+		ErrorRate:          0.5,
+		MinRequests:        10,
+		Window:             10000,
+		EjectionTime:       10000,
+		MaxEjectionTime:    300000,
+		BreakerErrorRate:   0.5,
+		BreakerMinRequests: 20,
+		BreakerOpenTimeout: 5000,
+	}
+)
+
+// Stats reports the passive health of every host of remote.
+func Stats(remote string) []HostStat {
+	poolsMu.Lock()
+	p, ok := pools[remote]
+	poolsMu.Unlock()
+	if !ok {
+		return nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stats := make([]HostStat, 0, len(p.hosts))
+	for _, h := range p.hosts {
+		stats = append(stats, HostStat{
+			Host:         h.addr,
+			Inflight:     atomic.LoadInt64(&h.inflight),
+			Requests:     h.requests,
+			Failures:     h.failures,
+			Latency:      h.latency,
+			Ejections:    h.ejections,
+			EjectedUntil: h.ejectedUntil,
+		})
+	}
+
+	return stats
+}
+
+func resetPools() {
+	poolsMu.Lock()
+	pools = make(map[string]*remotePool)
+	poolsMu.Unlock()
+}
+
+func getPool(remote string, addrs []string) *remotePool {
+	if len(addrs) == 0 {
+		return nil
+	}
+
+	poolsMu.Lock()
+	defer poolsMu.Unlock()
+
+	if p, ok := pools[remote]; ok {
+		return p
+	}
+
+	cfg := balancerDefault
+	if config != nil && config.Balancers[remote] != nil {
+		cfg = mergeBalancerConfig(*config.Balancers[remote])
+	}
+
+	p := &remotePool{name: remote, cfg: &cfg, windowStart: time.Now()}
+	for _, addr := range addrs {
+		weight := 1
+		if w, ok := cfg.Weights[addr]; ok && w > 0 {
+			weight = w
+		}
+
+		p.hosts = append(p.hosts, &host{addr: addr, weight: weight, windowStart: time.Now()})
+	}
+	pools[remote] = p
+
+	return p
+}
+
+func mergeBalancerConfig(c BalancerConfig) BalancerConfig {
+	d := balancerDefault
+	if c.Strategy == "" {
+		c.Strategy = d.Strategy
+	}
+	if c.MaxFailures == 0 {
+		c.MaxFailures = d.MaxFailures
+	}
+	if c.ErrorRate == 0 {
+		c.ErrorRate = d.ErrorRate
+	}
+	if c.MinRequests == 0 {
+		c.MinRequests = d.MinRequests
+	}
+	if c.Window == 0 {
+		c.Window = d.Window
+	}
+	if c.EjectionTime == 0 {
+		c.EjectionTime = d.EjectionTime
+	}
+	if c.MaxEjectionTime == 0 {
+		c.MaxEjectionTime = d.MaxEjectionTime
+	}
+	if c.BreakerErrorRate == 0 {
+		c.BreakerErrorRate = d.BreakerErrorRate
+	}
+	if c.BreakerMinRequests == 0 {
+		c.BreakerMinRequests = d.BreakerMinRequests
+	}
+	if c.BreakerOpenTimeout == 0 {
+		c.BreakerOpenTimeout = d.BreakerOpenTimeout
+	}
+
+	return c
+}
+
+// pick returns a healthy host, or every host when all are ejected, after
+// checking the remote breaker. Hosts in exclude are skipped unless nothing
+// else is left.
+func (p *remotePool) pick(exclude map[string]bool) (*host, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.allow(); err != nil {
+		return nil, err
+	}
+	probing := p.state == poolHalfOpen
+
+	now := time.Now()
+	var healthy, candidates []*host
+	for _, h := range p.hosts {
+		if exclude[h.addr] {
+			continue
+		}
+
+		candidates = append(candidates, h)
+		if now.After(h.ejectedUntil) {
+			healthy = append(healthy, h)
+		}
+	}
+
+	if len(candidates) == 0 {
+		candidates = p.hosts
+	}
+
+	if len(healthy) > 0 {
+		candidates = healthy
+	}
+
+	var h *host
+	switch p.cfg.Strategy {
+	case StrategyRoundRobin:
+		h = candidates[p.next%uint64(len(candidates))]
+		p.next++
+	case StrategyLeastRequests:
+		// power of two choices
+		h = candidates[rand.Intn(len(candidates))]
+		if o := candidates[rand.Intn(len(candidates))]; atomic.LoadInt64(&o.inflight) < atomic.LoadInt64(&h.inflight) {
+			h = o
+		}
+	case StrategyWeighted:
+		total := 0
+		for _, c := range candidates {
+			total += c.weight
+		}
+
+		n := rand.Intn(total)
+		for _, c := range candidates {
+			if n -= c.weight; n < 0 {
+				h = c
+				break
+			}
+		}
+	default:
+		h = candidates[rand.Intn(len(candidates))]
+	}
+
+	atomic.AddInt64(&h.inflight, 1)
+	if probing {
+		p.probe = h
+	}
+
+	return h, nil
+}
+
+func (p *remotePool) allow() error {
+	switch p.state {
+	case poolOpen:
+		if time.Since(p.openedAt) < p.cfg.BreakerOpenTimeout*time.Millisecond {
+			return ErrCircuitOpen
+		}
+
+		p.state = poolHalfOpen
+		p.probing, p.probe = false, nil
+		fallthrough
+	case poolHalfOpen:
+		if p.probing {
+			return ErrCircuitOpen
+		}
+
+		p.probing = true
+	}
+
+	return nil
+}
+
+// report records the outcome of a request to h; transport errors, 5xx and
+// responses slower than SlowThreshold count as failures.
+func (p *remotePool) report(h *host, latency time.Duration, status int, err error) {
+	atomic.AddInt64(&h.inflight, -1)
+
+	failed := err != nil || status >= 500
+	if slow := p.cfg.SlowThreshold * time.Millisecond; slow > 0 && latency > slow {
+		failed = true
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	window := p.cfg.Window * time.Millisecond
+
+	if h.latency == 0 {
+		h.latency = latency
+	} else {
+		h.latency = time.Duration(latencyDecay*float64(latency) + (1-latencyDecay)*float64(h.latency))
+	}
+
+	if now.Sub(h.windowStart) > window {
+		h.windowStart = now
+		h.requests, h.failures = 0, 0
+	}
+
+	h.requests++
+	if failed {
+		h.failures++
+		h.consecutive++
+	} else {
+		h.consecutive = 0
+		// forgive earlier ejections once the host stays healthy long enough
+		if h.ejections > 0 && now.Sub(h.ejectedUntil) > p.cfg.MaxEjectionTime*time.Millisecond {
+			h.ejections = 0
+		}
+	}
+
+	if failed && now.After(h.ejectedUntil) && (h.consecutive >= p.cfg.MaxFailures ||
+		h.requests >= p.cfg.MinRequests && float64(h.failures)/float64(h.requests) >= p.cfg.ErrorRate) {
+		p.eject(h, now)
+	}
+
+	p.record(h, failed, now)
+}
+
+func (p *remotePool) eject(h *host, now time.Time) {
+	d := time.Duration(float64(p.cfg.EjectionTime*time.Millisecond) * math.Pow(2, float64(h.ejections)))
+	if max := p.cfg.MaxEjectionTime * time.Millisecond; d > max {
+		d = max
+	}
+
+	h.ejections++
+	h.ejectedUntil = now.Add(d)
+	h.consecutive = 0
+	h.requests, h.failures = 0, 0
+
+	logs.Logger.Warn("[Client eject host]", zap.String("remote", p.name), zap.String("host", h.addr), zap.Duration("duration", d))
+}
+
+// record updates the remote breaker. While half-open only the probe decides,
+// late reports of requests picked before the breaker opened are dropped.
+func (p *remotePool) record(h *host, failed bool, now time.Time) {
+	if p.state == poolHalfOpen {
+		if !p.probing || p.probe != h {
+			return
+		}
+
+		p.probing, p.probe = false, nil
+		if failed {
+			p.state, p.openedAt = poolOpen, now
+			return
+		}
+
+		p.state = poolClosed
+		p.windowStart = now
+		p.requests, p.failures = 0, 0
+		return
+	}
+
+	if p.state != poolClosed {
+		return
+	}
+
+	if now.Sub(p.windowStart) > p.cfg.Window*time.Millisecond {
+		p.windowStart = now
+		p.requests, p.failures = 0, 0
+	}
+
+	p.requests++
+	if failed {
+		p.failures++
+	}
+
+	if p.requests >= p.cfg.BreakerMinRequests && float64(p.failures)/float64(p.requests) >= p.cfg.BreakerErrorRate {
+		p.state, p.openedAt = poolOpen, now
+		logs.Logger.Warn("[Client circuit open]", zap.String("remote", p.name))
+	}
+}
+
+// release gives back a picked host without recording an outcome. Releasing
+// the half-open probe lets the next request probe instead.
+func (p *remotePool) release(h *host) {
+	atomic.AddInt64(&h.inflight, -1)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.state == poolHalfOpen && p.probing && p.probe == h {
+		p.probing, p.probe = false, nil
+	}
+}
@@ -0,0 +1,234 @@
+package client
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func newTestPool(cfg BalancerConfig, addrs ...string) *remotePool {
+	cfg = mergeBalancerConfig(cfg)
+	p := &remotePool{name: "test", cfg: &cfg, windowStart: time.Now()}
+	for _, addr := range addrs {
+		p.hosts = append(p.hosts, &host{addr: addr, weight: 1, windowStart: time.Now()})
+	}
+
+	return p
+}
+
+func openPool(t *testing.T, p *remotePool) {
+	t.Helper()
+
+	for i := 0; i < p.cfg.BreakerMinRequests; i++ {
+		h, err := p.pick(nil)
+		if err != nil {
+			t.Fatalf("pick() = %v", err)
+		}
+		p.report(h, time.Millisecond, 500, nil)
+	}
+
+	if p.state != poolOpen {
+		t.Fatalf("state = %d, want open", p.state)
+	}
+
+	if _, err := p.pick(nil); err != ErrCircuitOpen {
+		t.Fatalf("pick() on open pool = %v, want ErrCircuitOpen", err)
+	}
+
+	// skip the open timeout
+	p.openedAt = time.Now().Add(-time.Hour)
+}
+
+func TestPoolBreaker(t *testing.T) {
+	tests := []struct {
+		name  string
+		probe func(p *remotePool, h *host)
+		want  int
+	}{
+		{"probe success closes", func(p *remotePool, h *host) {
+			p.report(h, time.Millisecond, 200, nil)
+		}, poolClosed},
+		{"probe failure reopens", func(p *remotePool, h *host) {
+			p.report(h, time.Millisecond, 0, errors.New("connection refused"))
+		}, poolOpen},
+		{"released probe is retried", func(p *remotePool, h *host) {
+			p.release(h)
+
+			next, err := p.pick(nil)
+			if err != nil {
+				t.Fatalf("pick() after release = %v", err)
+			}
+			p.report(next, time.Millisecond, 200, nil)
+		}, poolClosed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestPool(BalancerConfig{
+				MaxFailures:        100,
+				BreakerMinRequests: 2,
+				BreakerErrorRate:   0.5,
+			}, "a:80", "b:80")
+
+			openPool(t, p)
+			h, err := p.pick(nil)
+			if err != nil {
+				t.Fatalf("pick() probe = %v", err)
+			}
+
+			if p.state != poolHalfOpen {
+				t.Fatalf("state = %d, want half-open", p.state)
+			}
+
+			if _, err := p.pick(nil); err != ErrCircuitOpen {
+				t.Fatalf("pick() during probe = %v, want ErrCircuitOpen", err)
+			}
+
+			tt.probe(p, h)
+			if p.state != tt.want {
+				t.Fatalf("state = %d, want %d", p.state, tt.want)
+			}
+		})
+	}
+}
+
+func TestPoolReleaseOtherHost(t *testing.T) {
+	p := newTestPool(BalancerConfig{Strategy: StrategyRoundRobin, MaxFailures: 100, BreakerMinRequests: 1}, "a:80", "b:80", "c:80")
+
+	// picked before the breaker opened
+	stale, err := p.pick(nil)
+	if err != nil {
+		t.Fatalf("pick() = %v", err)
+	}
+
+	openPool(t, p)
+	probe, err := p.pick(nil)
+	if err != nil {
+		t.Fatalf("pick() probe = %v", err)
+	}
+
+	if probe == stale {
+		t.Fatal("probe picked the stale host")
+	}
+
+	p.release(stale)
+	if _, err := p.pick(nil); err != ErrCircuitOpen {
+		t.Fatalf("pick() after releasing a non-probe host = %v, want ErrCircuitOpen", err)
+	}
+}
+
+func TestPoolStaleReport(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+	}{
+		{"stale success", 200},
+		{"stale failure", 500},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestPool(BalancerConfig{Strategy: StrategyRoundRobin, MaxFailures: 100, BreakerMinRequests: 1}, "a:80", "b:80", "c:80")
+
+			// picked before the breaker opened
+			stale, err := p.pick(nil)
+			if err != nil {
+				t.Fatalf("pick() = %v", err)
+			}
+
+			openPool(t, p)
+			probe, err := p.pick(nil)
+			if err != nil {
+				t.Fatalf("pick() probe = %v", err)
+			}
+
+			if probe == stale {
+				t.Fatal("probe picked the stale host")
+			}
+
+			p.report(stale, time.Millisecond, tt.status, nil)
+			if p.state != poolHalfOpen {
+				t.Fatalf("state after stale report = %d, want half-open", p.state)
+			}
+
+			p.report(probe, time.Millisecond, 200, nil)
+			if p.state != poolClosed {
+				t.Fatalf("state after probe = %d, want closed", p.state)
+			}
+		})
+	}
+}
+
+func TestHostEjection(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      BalancerConfig
+		statuses []int
+		ejected  bool
+	}{
+		{"consecutive failures", BalancerConfig{MaxFailures: 3, MinRequests: 100}, []int{500, 500, 500}, true},
+		{"success resets consecutive", BalancerConfig{MaxFailures: 3, MinRequests: 100}, []int{500, 500, 200, 500}, false},
+		{"error rate", BalancerConfig{MaxFailures: 100, MinRequests: 4, ErrorRate: 0.5}, []int{200, 500, 200, 500}, true},
+		{"below min requests", BalancerConfig{MaxFailures: 100, MinRequests: 10, ErrorRate: 0.5}, []int{500, 500, 200}, false},
+		{"client errors are healthy", BalancerConfig{MaxFailures: 2, MinRequests: 100}, []int{404, 400, 429}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.BreakerMinRequests = 1000
+			p := newTestPool(tt.cfg, "a:80")
+			h := p.hosts[0]
+
+			for _, status := range tt.statuses {
+				if _, err := p.pick(nil); err != nil {
+					t.Fatalf("pick() = %v", err)
+				}
+				p.report(h, time.Millisecond, status, nil)
+			}
+
+			if ejected := time.Now().Before(h.ejectedUntil); ejected != tt.ejected {
+				t.Fatalf("ejected = %v, want %v", ejected, tt.ejected)
+			}
+
+			if h.inflight != 0 {
+				t.Fatalf("inflight = %d, want 0", h.inflight)
+			}
+		})
+	}
+}
+
+func TestEjectionBackoff(t *testing.T) {
+	p := newTestPool(BalancerConfig{EjectionTime: 1000, MaxEjectionTime: 3000}, "a:80")
+	h := p.hosts[0]
+
+	want := []time.Duration{time.Second, 2 * time.Second, 3 * time.Second}
+	for i, d := range want {
+		now := time.Now()
+		p.eject(h, now)
+		if got := h.ejectedUntil.Sub(now); got != d {
+			t.Fatalf("ejection %d = %v, want %v", i, got, d)
+		}
+	}
+}
+
+func TestPickSkipsEjectedHosts(t *testing.T) {
+	p := newTestPool(BalancerConfig{Strategy: StrategyRoundRobin}, "a:80", "b:80")
+	p.hosts[0].ejectedUntil = time.Now().Add(time.Hour)
+
+	for i := 0; i < 4; i++ {
+		h, err := p.pick(nil)
+		if err != nil {
+			t.Fatalf("pick() = %v", err)
+		}
+		p.release(h)
+
+		if h.addr != "b:80" {
+			t.Fatalf("pick() = %s, want b:80", h.addr)
+		}
+	}
+
+	p.hosts[1].ejectedUntil = time.Now().Add(time.Hour)
+	if h, err := p.pick(nil); err != nil || h == nil {
+		t.Fatalf("pick() with every host ejected = %v, %v", h, err)
+	}
+}
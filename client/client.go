@@ -2,6 +2,8 @@ package client
 
 import (
 	"github.com/opentracing/opentracing-go"
+	"github.com/shopastro/go-common/gorequest"
+	"github.com/shopastro/go-common/signature"
 	"golang.org/x/net/context"
 	"time"
 )
@@ -16,8 +18,14 @@ type (
 		Remotes       map[string][]string `json:"remotes" yaml:"remotes"`
 		Resp          IResponse
 		Tracer        opentracing.Tracer
-		Timeout       map[string]map[string]int `json:"httpTimeout"`
-		EnableMetrics bool                      `json:"enableMetrics" yaml:"enableMetrics"`
+		Timeout       map[string]map[string]int             `json:"httpTimeout"`
+		EnableMetrics bool                                  `json:"enableMetrics" yaml:"enableMetrics"`
+		Retry         map[string]map[string]*RetryConfig    `json:"httpRetry" yaml:"httpRetry"`
+		Balancers     map[string]*BalancerConfig            `json:"balancers" yaml:"balancers"`
+		Transports    map[string]*gorequest.TransportConfig `json:"transports" yaml:"transports"`
+		Middlewares   []gorequest.Middleware                `json:"-" yaml:"-"`
+		Signer        *signature.Signer                     `json:"-" yaml:"-"`
+		Recorder      *Recorder                             `json:"-" yaml:"-"`
 	}
 )
 
@@ -28,6 +36,11 @@ var (
 // init client
 func NewClient(cfg *Config) {
 	config = cfg
+	if cfg.Recorder != nil {
+		cfg.Recorder.setRemotes(cfg.Remotes)
+	}
+	resetPools()
+	gorequest.ResetTransports()
 }
 
 // get client
@@ -40,8 +53,19 @@ func GetClient(ctxs ...context.Context) *Client {
 		ctx = context.Background()
 	}
 
+	req := NewRequest(config.Remotes, ctx, config.Tracer, config.Debug, config.Timeout, config.EnableMetrics)
+	req.retryConfig = config.Retry
+	req.transports = config.Transports
+	req.SuperAgent.Use(config.Middlewares...)
+	if config.Signer != nil {
+		req.SuperAgent.Use(config.Signer.Middleware)
+	}
+	if config.Recorder != nil {
+		req.SuperAgent.Use(config.Recorder.Middleware)
+	}
+
 	return &Client{
-		request: NewRequest(config.Remotes, ctx, config.Tracer, config.Debug, config.Timeout, config.EnableMetrics),
+		request: req,
 	}
 }
 
@@ -0,0 +1,135 @@
+package client
+
+import (
+	"errors"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/shopastro/go-common/gorequest"
+	"github.com/shopastro/logs"
+	"go.uber.org/zap"
+)
+
+var (
+	clientLabels = []string{"remote", "path", "method", "class"}
+
+	clientReqCnt = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_client_requests_total",
+		Help: "How many outbound HTTP requests were made, partitioned by remote, path, method and result class.",
+	}, clientLabels)
+
+	clientReqDur = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "http_client_request_duration_seconds",
+		Help: "The outbound HTTP request latencies in seconds.",
+	}, clientLabels)
+
+	clientReqSz = prometheus.NewSummaryVec(prometheus.SummaryOpts{
+		Name: "http_client_request_size_bytes",
+		Help: "The outbound HTTP request sizes in bytes.",
+	}, clientLabels)
+
+	clientResSz = prometheus.NewSummaryVec(prometheus.SummaryOpts{
+		Name: "http_client_response_size_bytes",
+		Help: "The outbound HTTP response sizes in bytes.",
+	}, clientLabels)
+
+	metricsOnce sync.Once
+
+	// segments that carry ids are collapsed to keep the path label bounded
+	idSegment = regexp.MustCompile(`^([0-9]+|[0-9a-fA-F-]{16,}|[0-9a-zA-Z_-]{32,})$`)
+)
+
+func registerMetrics() {
+	for name, c := range map[string]prometheus.Collector{
+		"http_client_requests_total":           clientReqCnt,
+		"http_client_request_duration_seconds": clientReqDur,
+		"http_client_request_size_bytes":       clientReqSz,
+		"http_client_response_size_bytes":      clientResSz,
+	} {
+		if err := prometheus.Register(c); err != nil {
+			if _, ok := err.(prometheus.AlreadyRegisteredError); !ok {
+				logs.Logger.Error("[Prometheus registered]", zap.String("name", name), zap.Error(err))
+			}
+		}
+	}
+}
+
+// observe records one attempt when EnableMetrics is set.
+func (svc *request) observe(method string, res gorequest.Response, body string, errs []error, elapsed time.Duration) {
+	if !svc.enableMetrics {
+		return
+	}
+
+	metricsOnce.Do(registerMetrics)
+
+	remote := svc.remoteName
+	if remote == "" {
+		remote = "unknown"
+	}
+
+	labels := prometheus.Labels{
+		"remote": remote,
+		"path":   normalizePath(svc.path),
+		"method": method,
+		"class":  classify(res, errs),
+	}
+
+	clientReqCnt.With(labels).Inc()
+	clientReqDur.With(labels).Observe(elapsed.Seconds())
+	clientResSz.With(labels).Observe(float64(len(body)))
+	if res != nil && res.Request != nil && res.Request.ContentLength > 0 {
+		clientReqSz.With(labels).Observe(float64(res.Request.ContentLength))
+	} else {
+		clientReqSz.With(labels).Observe(0)
+	}
+}
+
+func normalizePath(path string) string {
+	if i := strings.IndexAny(path, "?#"); i >= 0 {
+		path = path[:i]
+	}
+
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i, seg := range segments {
+		if idSegment.MatchString(seg) {
+			segments[i] = ":id"
+		}
+	}
+
+	return "/" + strings.ToLower(strings.Join(segments, "/"))
+}
+
+// classify maps an attempt to its HTTP status class or the kind of failure.
+func classify(res gorequest.Response, errs []error) string {
+	if len(errs) == 0 && res != nil {
+		return strconv.Itoa(res.StatusCode/100) + "xx"
+	}
+
+	var err error
+	if len(errs) > 0 {
+		err = errs[0]
+	}
+
+	var dnsErr *net.DNSError
+
+	switch {
+	case err == nil:
+		return "error"
+	case errors.Is(err, ErrCircuitOpen):
+		return "circuit_open"
+	case errors.As(err, &dnsErr):
+		return "dns"
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return "refused"
+	case isTimeout(err):
+		return "timeout"
+	default:
+		return "error"
+	}
+}
@@ -0,0 +1,294 @@
+package client
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/shopastro/go-common/gorequest"
+)
+
+type (
+	// Recorder is a transport for tests, set it as Config.Recorder:
+	//   - RecordMode sends requests and saves each exchange to a golden file in Dir
+	//   - ReplayMode serves from the golden files and fails on unmatched requests
+	//   - StubMode answers from Stubs and fails on unmatched requests
+	// Golden files are matched on remote, method, path, query and body.
+	Recorder struct {
+		Mode  string
+		Dir   string
+		Stubs []*Stub
+
+		mu      sync.RWMutex
+		remotes map[string][]string
+	}
+
+	// Stub matches on the non-empty fields; Path is a path.Match pattern.
+	Stub struct {
+		Remote       string
+		Method       string
+		Path         string
+		BodyContains string
+		Match        func(req *http.Request, body []byte) bool
+
+		Status int
+		Header http.Header
+		// Body is written as is when it is a string or []byte, else as JSON.
+		Body interface{}
+		Err  error
+	}
+
+	recording struct {
+		Request  recordedRequest  `json:"request"`
+		Response recordedResponse `json:"response"`
+	}
+
+	recordedRequest struct {
+		Remote string `json:"remote"`
+		Method string `json:"method"`
+		Path   string `json:"path"`
+		Query  string `json:"query,omitempty"`
+		Body   string `json:"body,omitempty"`
+	}
+
+	recordedResponse struct {
+		Status int         `json:"status"`
+		Header http.Header `json:"header,omitempty"`
+		Body   string      `json:"body"`
+	}
+)
+
+const (
+	RecordMode = "record"
+	ReplayMode = "replay"
+	StubMode   = "stub"
+)
+
+var ErrUnmatched = errors.New("client: no recording or stub matches the request")
+
+func NewRecorder(mode, dir string) *Recorder {
+	return &Recorder{Mode: mode, Dir: dir}
+}
+
+func NewStubRecorder(stubs ...*Stub) *Recorder {
+	return &Recorder{Mode: StubMode, Stubs: stubs}
+}
+
+// Stub adds a stub and returns the recorder.
+func (r *Recorder) Stub(stub *Stub) *Recorder {
+	r.mu.Lock()
+	r.Stubs = append(r.Stubs, stub)
+	r.mu.Unlock()
+
+	return r
+}
+
+func (r *Recorder) setRemotes(remotes map[string][]string) {
+	r.mu.Lock()
+	r.remotes = remotes
+	r.mu.Unlock()
+}
+
+func (r *Recorder) Middleware(next http.RoundTripper) http.RoundTripper {
+	return gorequest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		body, err := readRequestBody(req)
+		if err != nil {
+			return nil, err
+		}
+
+		rr := recordedRequest{
+			Remote: r.remoteOf(req),
+			Method: req.Method,
+			Path:   req.URL.Path,
+			Query:  req.URL.Query().Encode(),
+			Body:   string(body),
+		}
+
+		switch r.Mode {
+		case RecordMode:
+			return r.record(next, req, rr)
+		case ReplayMode:
+			return r.replay(req, rr)
+		case StubMode:
+			return r.stub(req, rr, body)
+		default:
+			return nil, fmt.Errorf("client: unknown recorder mode %q", r.Mode)
+		}
+	})
+}
+
+func (r *Recorder) record(next http.RoundTripper, req *http.Request, rr recordedRequest) (*http.Response, error) {
+	resp, err := next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.MarshalIndent(recording{
+		Request:  rr,
+		Response: recordedResponse{Status: resp.StatusCode, Header: resp.Header, Body: string(body)},
+	}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	file := r.file(rr)
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return nil, err
+	}
+
+	return resp, ioutil.WriteFile(file, data, 0644)
+}
+
+func (r *Recorder) replay(req *http.Request, rr recordedRequest) (*http.Response, error) {
+	data, err := ioutil.ReadFile(r.file(rr))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s %s %s", ErrUnmatched, rr.Remote, rr.Method, req.URL.RequestURI())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var rec recording
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, err
+	}
+
+	return newResponse(req, rec.Response.Status, rec.Response.Header, []byte(rec.Response.Body)), nil
+}
+
+func (r *Recorder) stub(req *http.Request, rr recordedRequest, body []byte) (*http.Response, error) {
+	r.mu.RLock()
+	stubs := r.Stubs
+	r.mu.RUnlock()
+
+	for _, s := range stubs {
+		if !s.matches(req, rr, body) {
+			continue
+		}
+
+		if s.Err != nil {
+			return nil, s.Err
+		}
+
+		data, err := s.body()
+		if err != nil {
+			return nil, err
+		}
+
+		status := s.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		return newResponse(req, status, s.Header, data), nil
+	}
+
+	return nil, fmt.Errorf("%w: %s %s %s", ErrUnmatched, rr.Remote, rr.Method, req.URL.RequestURI())
+}
+
+func (s *Stub) matches(req *http.Request, rr recordedRequest, body []byte) bool {
+	if s.Remote != "" && s.Remote != rr.Remote {
+		return false
+	}
+
+	if s.Method != "" && !strings.EqualFold(s.Method, rr.Method) {
+		return false
+	}
+
+	if s.Path != "" {
+		if ok, _ := path.Match(s.Path, rr.Path); !ok {
+			return false
+		}
+	}
+
+	if s.BodyContains != "" && !bytes.Contains(body, []byte(s.BodyContains)) {
+		return false
+	}
+
+	return s.Match == nil || s.Match(req, body)
+}
+
+func (s *Stub) body() ([]byte, error) {
+	switch b := s.Body.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []byte(b), nil
+	case []byte:
+		return b, nil
+	default:
+		return json.Marshal(b)
+	}
+}
+
+// remoteOf maps the request back to the name of the remote it was sent to.
+func (r *Recorder) remoteOf(req *http.Request) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	origin := req.URL.Scheme + "://" + req.URL.Host
+	for name, hosts := range r.remotes {
+		for _, h := range hosts {
+			if strings.TrimRight(h, "/") == origin || strings.HasPrefix(origin+req.URL.Path, strings.TrimRight(h, "/")+"/") {
+				return name
+			}
+		}
+	}
+
+	return req.URL.Host
+}
+
+// file is Dir/<remote>/<method>_<path>_<hash>.json
+func (r *Recorder) file(rr recordedRequest) string {
+	sum := sha1.Sum([]byte(rr.Method + " " + rr.Path + "?" + rr.Query + "\n" + rr.Body))
+	name := strings.Trim(strings.NewReplacer("/", "_", ":", "_").Replace(rr.Path), "_")
+
+	return filepath.Join(r.Dir, rr.Remote, fmt.Sprintf("%s_%s_%s.json", strings.ToLower(rr.Method), name, hex.EncodeToString(sum[:6])))
+}
+
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+
+	body, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	return body, err
+}
+
+func newResponse(req *http.Request, status int, header http.Header, body []byte) *http.Response {
+	if header == nil {
+		header = make(http.Header)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
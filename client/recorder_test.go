@@ -0,0 +1,86 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRecorderRoundTrip(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":` + r.URL.Query().Get("id") + `,"title":"recorded"}`))
+	}))
+	remotes := map[string][]string{"api": {srv.URL}}
+	dir := t.TempDir()
+
+	tests := []struct {
+		name string
+		mode string
+		id   string
+		want error
+	}{
+		{"record", RecordMode, "1", nil},
+		{"replay", ReplayMode, "1", nil},
+		{"replay unrecorded", ReplayMode, "2", ErrUnmatched},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.mode == ReplayMode {
+				// replay must not reach the server
+				srv.Close()
+			}
+
+			NewClient(&Config{Remotes: remotes, Recorder: NewRecorder(tt.mode, dir)})
+
+			out, _, err := Do[article](context.Background(), "api", "/articles", WithQuery(map[string]string{"id": tt.id}))
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Do() = %v, want %v", err, tt.want)
+			}
+
+			if tt.want == nil && (out.Id != 1 || out.Title != "recorded") {
+				t.Fatalf("Do() = %+v", out)
+			}
+		})
+	}
+}
+
+func TestRecorderStub(t *testing.T) {
+	stubs := []*Stub{
+		{Remote: "api", Method: http.MethodGet, Path: "/articles/*", Body: article{Id: 3, Title: "stubbed"}},
+		{Remote: "api", Method: http.MethodPost, Path: "/articles", Status: http.StatusConflict, Body: "exists"},
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		want   error
+	}{
+		{"hit", http.MethodGet, "/articles/3", nil},
+		{"hit with status", http.MethodPost, "/articles", ErrStatus},
+		{"miss on path", http.MethodGet, "/users/3", ErrUnmatched},
+		{"miss on method", http.MethodDelete, "/articles/3", ErrUnmatched},
+	}
+
+	NewClient(&Config{
+		Remotes:  map[string][]string{"api": {"http://api.invalid"}},
+		Recorder: NewStubRecorder(stubs...),
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, _, err := Do[article](context.Background(), "api", tt.path, WithMethod(tt.method))
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Do() = %v, want %v", err, tt.want)
+			}
+
+			if tt.want == nil && (out.Id != 3 || out.Title != "stubbed") {
+				t.Fatalf("Do() = %+v", out)
+			}
+		})
+	}
+}
@@ -1,17 +1,14 @@
 package client
 
 import (
-	"crypto/tls"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"strings"
 	"time"
 
 	"github.com/opentracing/opentracing-go"
-	"github.com/shopastro/go-common/common"
 	"github.com/shopastro/go-common/gorequest"
-	"github.com/shopastro/logs"
-	"go.uber.org/zap"
 	"golang.org/x/net/context"
 )
 
@@ -29,6 +26,15 @@ type (
 		ctx                context.Context
 		Response           IResponse
 		enableMetrics      bool
+		pool               *remotePool
+		host               *host
+		remoteErr          error
+		picked             time.Time
+		remoteName         string
+		path               string
+		retryConfig        map[string]map[string]*RetryConfig
+		attempts           int
+		transports         map[string]*gorequest.TransportConfig
 	}
 )
 
@@ -50,13 +56,26 @@ func NewRequest(remote map[string][]string, ctx context.Context, trace opentraci
 	}
 }
 
-func (svc *request) insecureSkipVerify() {
+// useTransport sends through the pooled transport of the remote, see
+// Config.Transports. InsecureSkipVerify gets a pool of its own.
+func (svc *request) useTransport() {
+	cfg, ok := svc.transports[svc.remoteName]
+	if !ok {
+		cfg = svc.transports["default"]
+	}
+
+	key := svc.remoteName
 	if svc.InsecureSkipVerify {
-		svc.SuperAgent.TLSClientConfig(
-			&tls.Config{
-				InsecureSkipVerify: svc.InsecureSkipVerify,
-			})
+		insecure := gorequest.TransportConfig{}
+		if cfg != nil {
+			insecure = *cfg
+		}
+
+		insecure.InsecureSkipVerify = true
+		cfg, key = &insecure, key+"#insecure"
 	}
+
+	svc.SuperAgent.UseTransport(gorequest.SharedTransport(key, cfg))
 }
 
 // set request time out config
@@ -81,18 +100,54 @@ func (svc *request) SetTimeOut(times time.Duration) {
 	svc.timeOut = times
 }
 
+// SetRemote picks a host of remote through its pool, see BalancerConfig.
 func (svc *request) SetRemote(remote string) *request {
-	remoteArray, ok := svc.remotes[remote]
-	if ok {
-		max := len(remoteArray)
-		num := common.NewTools().GenerateRangeNum(0, max)
-		svc.remote = remoteArray[num]
+	p := getPool(remote, svc.remotes[remote])
+	if p == nil {
+		return svc
+	}
+
+	svc.pool = p
+	svc.remoteName = remote
+	svc.host, svc.remoteErr = p.pick(nil)
+	svc.picked = time.Now()
+	svc.remote = ""
+	if svc.host != nil {
+		svc.remote = svc.host.addr
 	}
 
 	return svc
 }
 
+// report feeds the outcome back into the pool the host was picked from.
+func (svc *request) report(res gorequest.Response, errs []error) {
+	if svc.host == nil {
+		return
+	}
+
+	status := 0
+	if res != nil {
+		status = res.StatusCode
+	}
+
+	var err error
+	if len(errs) > 0 {
+		err = errs[0]
+	}
+
+	svc.pool.report(svc.host, time.Since(svc.picked), status, err)
+	svc.host = nil
+}
+
+func (svc *request) release() {
+	if svc.host != nil {
+		svc.pool.release(svc.host)
+		svc.host = nil
+	}
+}
+
 func (svc *request) SetPath(path string) *request {
+	svc.path = path
 	path = strings.TrimRight(strings.TrimLeft(path, "/"), "/")
 
 	svc.url = fmt.Sprintf("%s/%s",
@@ -111,85 +166,67 @@ func (svc *request) SetParam(param interface{}) *request {
 }
 
 func (svc *request) Get() IResponse {
-	svc.SuperAgent.Timeout(svc.timeOut).Get(svc.url)
-	svc.SuperAgent.Header = svc.Header
-	svc.insecureSkipVerify()
-
-	res, body, err := svc.SuperAgent.Query(svc.param).End()
-	if err == nil && res != nil {
-		return svc.Response.SetBody(body, res.StatusCode)
+	return svc.send("[Get]", http.MethodGet, func() (gorequest.Response, string, []error) {
+		svc.SuperAgent.Timeout(svc.timeOut).Get(svc.url)
+		svc.SuperAgent.Header = svc.Header
+		svc.useTransport()
 
-	}
-	logs.Logger.Error("[Get]", zap.Any("err", err), zap.String("remote", svc.remote), zap.Any("param", svc.param))
-	return svc.Response.SetBody(DefualtJson)
+		return svc.SuperAgent.Query(svc.param).End()
+	})
 }
 
 func (svc *request) Post() IResponse {
-	svc.SuperAgent.Timeout(svc.timeOut).Post(svc.url).Send(svc.param)
-	svc.SuperAgent.Header = svc.Header
-	svc.insecureSkipVerify()
+	return svc.send("[Post]", http.MethodPost, func() (gorequest.Response, string, []error) {
+		svc.SuperAgent.Timeout(svc.timeOut).Post(svc.url).Send(svc.param)
+		svc.SuperAgent.Header = svc.Header
+		svc.useTransport()
 
-	res, body, err := svc.SuperAgent.End()
-	if err == nil && res != nil {
-		return svc.Response.SetBody(body, res.StatusCode)
-	}
-	logs.Logger.Error("[Post]", zap.Any("err", err), zap.String("remote", svc.remote), zap.Any("param", svc.param))
-	return svc.Response.SetBody(DefualtJson)
+		return svc.SuperAgent.End()
+	})
 }
 
 func (svc *request) PostUrlEncode() IResponse {
-	svc.SuperAgent.Timeout(svc.timeOut).Post(svc.url).Send(svc.param)
-	svc.SuperAgent.Header = svc.Header
-	svc.insecureSkipVerify()
+	return svc.send("[PostUrlEncode]", http.MethodPost, func() (gorequest.Response, string, []error) {
+		svc.SuperAgent.Timeout(svc.timeOut).Post(svc.url).Send(svc.param)
+		svc.SuperAgent.Header = svc.Header
+		svc.useTransport()
 
-	res, body, err := svc.SuperAgent.End()
-	if err == nil && res != nil {
-		return svc.Response.SetBody(body, res.StatusCode)
-	}
-	logs.Logger.Error("[PostUrlEncode]", zap.Any("err", err), zap.String("remote", svc.remote), zap.Any("param", svc.param))
-	return svc.Response.SetBody(DefualtJson)
+		return svc.SuperAgent.End()
+	})
 }
 
 func (svc *request) Put() IResponse {
-	svc.SuperAgent.Timeout(svc.timeOut).Put(svc.url).Send(svc.param)
-	svc.SuperAgent.Header = svc.Header
-	svc.insecureSkipVerify()
+	return svc.send("[Put]", http.MethodPut, func() (gorequest.Response, string, []error) {
+		svc.SuperAgent.Timeout(svc.timeOut).Put(svc.url).Send(svc.param)
+		svc.SuperAgent.Header = svc.Header
+		svc.useTransport()
 
-	res, body, err := svc.SuperAgent.End()
-	if err == nil && res != nil {
-		return svc.Response.SetBody(body, res.StatusCode)
-	}
-	logs.Logger.Error("[Put]", zap.Any("err", err), zap.String("remote", svc.remote), zap.Any("param", svc.param))
-	return svc.Response.SetBody(DefualtJson)
+		return svc.SuperAgent.End()
+	})
 }
 
 func (svc *request) PostJson() IResponse {
-	svc.SuperAgent.Timeout(svc.timeOut).Post(svc.url)
-	svc.SuperAgent.Header = svc.Header
-	svc.insecureSkipVerify()
-
 	paramsJson, errMsg := json.Marshal(svc.param)
 	if errMsg != nil {
+		svc.release()
 		return svc.Response.SetBody(DefualtJson, 406)
 	}
 
-	res, body, err := svc.SuperAgent.Send(string(paramsJson)).End()
-	if err == nil && res != nil {
-		return svc.Response.SetBody(body, res.StatusCode)
-	}
-	logs.Logger.Error("[PostJson]", zap.Any("err", err), zap.String("remote", svc.remote), zap.Any("param", svc.param))
-	return svc.Response.SetBody(DefualtJson)
+	return svc.send("[PostJson]", http.MethodPost, func() (gorequest.Response, string, []error) {
+		svc.SuperAgent.Timeout(svc.timeOut).Post(svc.url)
+		svc.SuperAgent.Header = svc.Header
+		svc.useTransport()
+
+		return svc.SuperAgent.Send(string(paramsJson)).End()
+	})
 }
 
 func (svc *request) Delete() IResponse {
-	svc.SuperAgent.Timeout(svc.timeOut).Delete(svc.url).Send(svc.param)
-	svc.SuperAgent.Header = svc.Header
-	svc.insecureSkipVerify()
+	return svc.send("[Delete]", http.MethodDelete, func() (gorequest.Response, string, []error) {
+		svc.SuperAgent.Timeout(svc.timeOut).Delete(svc.url).Send(svc.param)
+		svc.SuperAgent.Header = svc.Header
+		svc.useTransport()
 
-	res, body, err := svc.SuperAgent.End()
-	if err == nil && res != nil {
-		return svc.Response.SetBody(body, res.StatusCode)
-	}
-	logs.Logger.Error("[Delete]", zap.Any("err", err), zap.String("remote", svc.remote), zap.Any("param", svc.param))
-	return svc.Response.SetBody(DefualtJson)
+		return svc.SuperAgent.End()
+	})
 }
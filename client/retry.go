@@ -0,0 +1,219 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/shopastro/go-common/common"
+	"github.com/shopastro/go-common/gorequest"
+	"github.com/shopastro/logs"
+	"go.uber.org/zap"
+)
+
+type (
+	// RetryConfig retries connection errors and Status codes of idempotent
+	// methods, or of every method with NonIdempotent. Attempts counts every
+	// send including the first. Each retry goes to another host of the remote
+	// after a jittered backoff capped by MaxBackoff (milliseconds).
+	RetryConfig struct {
+		Attempts      int   `json:"attempts" yaml:"attempts"`
+		Status        []int `json:"status" yaml:"status"`
+		NonIdempotent bool  `json:"nonIdempotent" yaml:"nonIdempotent"`
+		MaxBackoff    int   `json:"maxBackoff" yaml:"maxBackoff"`
+	}
+)
+
+var retryStatusDefault = []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout}
+
+// retryPolicy looks up the path, then the "default" entry of the remote.
+func (svc *request) retryPolicy() *RetryConfig {
+	rc, ok := svc.retryConfig[svc.remoteName]
+	if !ok {
+		return nil
+	}
+
+	if p, ok := rc[strings.ToLower(svc.path)]; ok {
+		return p
+	}
+
+	return rc["default"]
+}
+
+// send runs the exchange and renders failures as DefualtJson.
+func (svc *request) send(name, method string, do func() (gorequest.Response, string, []error)) IResponse {
+	res, body, errs := svc.exchange(name, method, do)
+	if errs == nil && res != nil {
+		return svc.Response.SetBody(body, res.StatusCode)
+	}
+
+	logs.Logger.Error(name, zap.Any("err", errs), zap.String("remote", svc.remote), zap.Any("param", svc.param))
+	return svc.Response.SetBody(DefualtJson)
+}
+
+// exchange runs do until it succeeds, the retry policy gives up or the
+// deadline of the request context is reached.
+func (svc *request) exchange(name, method string, do func() (gorequest.Response, string, []error)) (gorequest.Response, string, []error) {
+	policy := svc.retryPolicy()
+	tried := make(map[string]bool)
+
+	for svc.attempts = 1; ; svc.attempts++ {
+		if svc.remoteErr != nil {
+			svc.observe(method, nil, "", []error{svc.remoteErr}, 0)
+			return nil, "", []error{svc.remoteErr}
+		}
+
+		if err := svc.applyDeadline(); err != nil {
+			svc.release()
+			return nil, "", []error{err}
+		}
+
+		tried[svc.remote] = true
+		start := time.Now()
+		res, body, errs := do()
+		svc.report(res, errs)
+		svc.observe(method, res, body, errs, time.Since(start))
+
+		if policy.retryable(method, res, errs) && svc.attempts < policy.Attempts && svc.backoff(policy, svc.attempts) && svc.reselect(tried) {
+			logs.Logger.Warn(name+" retry", zap.Int("attempt", svc.attempts), zap.String("remote", svc.remote))
+			continue
+		}
+
+		return res, body, errs
+	}
+}
+
+// applyDeadline shortens the timeout to what is left of the context
+// deadline and fails once the context is done.
+func (svc *request) applyDeadline() error {
+	if svc.ctx == nil {
+		return nil
+	}
+
+	if err := svc.ctx.Err(); err != nil {
+		return err
+	}
+
+	if deadline, ok := svc.ctx.Deadline(); ok {
+		left := time.Until(deadline)
+		if left <= 0 {
+			return context.DeadlineExceeded
+		}
+
+		if left < svc.timeOut {
+			svc.timeOut = left
+		}
+	}
+
+	return nil
+}
+
+func (svc *request) backoff(policy *RetryConfig, attempt int) bool {
+	wait := common.NewTools().DoJitter(attempt)
+	if max := time.Duration(policy.MaxBackoff) * time.Millisecond; max > 0 && wait > max {
+		wait = max
+	}
+
+	if svc.ctx == nil {
+		time.Sleep(wait)
+		return true
+	}
+
+	if deadline, ok := svc.ctx.Deadline(); ok && time.Until(deadline) <= wait {
+		return false
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-svc.ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// reselect moves the request to a host that has not been tried yet.
+func (svc *request) reselect(tried map[string]bool) bool {
+	if svc.pool == nil {
+		return true
+	}
+
+	h, err := svc.pool.pick(tried)
+	if err != nil {
+		return false
+	}
+
+	svc.host, svc.picked, svc.remote = h, time.Now(), h.addr
+	svc.SetPath(svc.path)
+
+	return true
+}
+
+func (policy *RetryConfig) retryable(method string, res gorequest.Response, errs []error) bool {
+	if policy == nil || policy.Attempts <= 1 {
+		return false
+	}
+
+	if !policy.NonIdempotent && !idempotent(method) {
+		return false
+	}
+
+	if len(errs) > 0 {
+		for _, err := range errs {
+			if !connError(err) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	if res == nil {
+		return false
+	}
+
+	status := policy.Status
+	if len(status) == 0 {
+		status = retryStatusDefault
+	}
+
+	for _, s := range status {
+		if s == res.StatusCode {
+			return true
+		}
+	}
+
+	return false
+}
+
+// connError reports dial, refused, reset and timeout errors; cancellations
+// and deadlines of the caller's context are not retried.
+func connError(err error) bool {
+	var opErr *net.OpError
+
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return false
+	case errors.As(err, &opErr) && opErr.Op == "dial":
+		return true
+	case errors.Is(err, syscall.ECONNREFUSED), errors.Is(err, syscall.ECONNRESET):
+		return true
+	default:
+		return isTimeout(err)
+	}
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete, http.MethodTrace:
+		return true
+	default:
+		return false
+	}
+}
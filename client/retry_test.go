@@ -0,0 +1,55 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"testing"
+
+	"github.com/shopastro/go-common/gorequest"
+)
+
+func TestRetryable(t *testing.T) {
+	policy := &RetryConfig{Attempts: 3}
+	dial := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("no route to host")}
+
+	tests := []struct {
+		name   string
+		policy *RetryConfig
+		method string
+		status int
+		errs   []error
+		want   bool
+	}{
+		{"no policy", nil, http.MethodGet, 0, []error{dial}, false},
+		{"single attempt", &RetryConfig{Attempts: 1}, http.MethodGet, 0, []error{dial}, false},
+		{"dial", policy, http.MethodGet, 0, []error{dial}, true},
+		{"refused", policy, http.MethodGet, 0, []error{&url.Error{Op: "Get", Err: syscall.ECONNREFUSED}}, true},
+		{"reset", policy, http.MethodGet, 0, []error{syscall.ECONNRESET}, true},
+		{"caller canceled", policy, http.MethodGet, 0, []error{&url.Error{Op: "Get", Err: context.Canceled}}, false},
+		{"caller deadline", policy, http.MethodGet, 0, []error{context.DeadlineExceeded}, false},
+		{"body too large", policy, http.MethodGet, 0, []error{gorequest.ErrBodyTooLarge}, false},
+		{"encode error", policy, http.MethodGet, 0, []error{errors.New("json: unsupported type")}, false},
+		{"non idempotent", policy, http.MethodPost, 0, []error{dial}, false},
+		{"non idempotent allowed", &RetryConfig{Attempts: 3, NonIdempotent: true}, http.MethodPost, 0, []error{dial}, true},
+		{"default status", policy, http.MethodGet, http.StatusServiceUnavailable, nil, true},
+		{"other status", policy, http.MethodGet, http.StatusInternalServerError, nil, false},
+		{"configured status", &RetryConfig{Attempts: 3, Status: []int{http.StatusTooManyRequests}}, http.MethodGet, http.StatusTooManyRequests, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var res gorequest.Response
+			if tt.status > 0 {
+				res = &http.Response{StatusCode: tt.status}
+			}
+
+			if got := tt.policy.retryable(tt.method, res, tt.errs); got != tt.want {
+				t.Fatalf("retryable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/shopastro/go-common/gorequest"
+)
+
+type (
+	// Meta describes the final attempt of a Do call.
+	Meta struct {
+		Status   int
+		Header   http.Header
+		Host     string
+		Latency  time.Duration
+		Attempts int
+	}
+
+	DoOption func(*doOptions)
+
+	doOptions struct {
+		method   string
+		query    interface{}
+		body     interface{}
+		bodyType string
+		header   http.Header
+		timeout  time.Duration
+	}
+
+	// RequestError wraps a failure to get a response; Kind is ErrTimeout or
+	// ErrTransport and Err the underlying cause.
+	RequestError struct {
+		Kind   error
+		Remote string
+		Host   string
+		Err    error
+	}
+
+	StatusError struct {
+		Status int
+		Body   string
+	}
+
+	DecodeError struct {
+		Body string
+		Err  error
+	}
+)
+
+var (
+	ErrTransport = errors.New("client: transport error")
+	ErrTimeout   = errors.New("client: timeout")
+	ErrStatus    = errors.New("client: unexpected status")
+	ErrDecode    = errors.New("client: decode response")
+	// ErrUnknownRemote is returned by Do for a remote without hosts.
+	ErrUnknownRemote = errors.New("client: unknown remote")
+)
+
+func WithMethod(method string) DoOption {
+	return func(o *doOptions) {
+		o.method = method
+	}
+}
+
+func WithQuery(query interface{}) DoOption {
+	return func(o *doOptions) {
+		o.query = query
+	}
+}
+
+// WithBody sends body as JSON.
+func WithBody(body interface{}) DoOption {
+	return func(o *doOptions) {
+		o.body = body
+		o.bodyType = gorequest.TypeJSON
+	}
+}
+
+// WithForm sends body url encoded.
+func WithForm(body interface{}) DoOption {
+	return func(o *doOptions) {
+		o.body = body
+		o.bodyType = gorequest.TypeForm
+	}
+}
+
+func WithHeader(key, value string) DoOption {
+	return func(o *doOptions) {
+		o.header.Add(key, value)
+	}
+}
+
+func WithTimeout(timeout time.Duration) DoOption {
+	return func(o *doOptions) {
+		o.timeout = timeout
+	}
+}
+
+// Do calls path on remote and decodes a 2xx JSON body into T. Failures are
+// returned as *RequestError, *StatusError or *DecodeError, which match
+// ErrTransport/ErrTimeout, ErrStatus and ErrDecode with errors.Is.
+func Do[T any](ctx context.Context, remote, path string, opts ...DoOption) (T, *Meta, error) {
+	var out T
+
+	if config == nil || len(config.Remotes[remote]) == 0 {
+		return out, &Meta{}, fmt.Errorf("%w: %s", ErrUnknownRemote, remote)
+	}
+
+	o := &doOptions{method: http.MethodGet, header: make(http.Header)}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	req := GetClient(ctx).request
+	req.SetRemote(remote).SetPath(path).SetTimeOutConfig(remote, path)
+	req.Header = o.header
+	if o.timeout > 0 {
+		req.SetTimeOut(o.timeout)
+	}
+
+	start := time.Now()
+	res, body, errs := req.exchange("[Do]", o.method, func() (gorequest.Response, string, []error) {
+		req.SuperAgent.Timeout(req.timeOut).CustomMethod(o.method, req.url)
+		req.SuperAgent.Header = req.Header
+		req.useTransport()
+
+		if o.query != nil {
+			req.SuperAgent.Query(o.query)
+		}
+
+		if o.body != nil {
+			req.SuperAgent.Type(o.bodyType).Send(o.body)
+		}
+
+		return req.SuperAgent.End()
+	})
+
+	meta := &Meta{Host: req.remote, Latency: time.Since(start), Attempts: req.attempts}
+	if len(errs) > 0 || res == nil {
+		return out, meta, newRequestError(remote, req.remote, errs)
+	}
+
+	meta.Status, meta.Header = res.StatusCode, res.Header
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return out, meta, &StatusError{Status: res.StatusCode, Body: body}
+	}
+
+	if body == "" {
+		return out, meta, nil
+	}
+
+	if err := json.Unmarshal([]byte(body), &out); err != nil {
+		return out, meta, &DecodeError{Body: body, Err: err}
+	}
+
+	return out, meta, nil
+}
+
+func newRequestError(remote, host string, errs []error) *RequestError {
+	err := errors.New("no response")
+	if len(errs) > 0 {
+		err = errs[0]
+	}
+
+	kind := ErrTransport
+	if isTimeout(err) {
+		kind = ErrTimeout
+	}
+
+	return &RequestError{Kind: kind, Remote: remote, Host: host, Err: err}
+}
+
+func isTimeout(err error) bool {
+	var netErr net.Error
+
+	return errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) && netErr.Timeout()
+}
+
+func (e *RequestError) Error() string {
+	return fmt.Sprintf("%s: %s %s: %v", e.Kind, e.Remote, e.Host, e.Err)
+}
+
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
+
+func (e *RequestError) Is(target error) bool {
+	return target == e.Kind
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s: %d", ErrStatus, e.Status)
+}
+
+func (e *StatusError) Is(target error) bool {
+	return target == ErrStatus
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("%s: %v", ErrDecode, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+func (e *DecodeError) Is(target error) bool {
+	return target == ErrDecode
+}
@@ -0,0 +1,66 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type article struct {
+	Id    int    `json:"id"`
+	Title string `json:"title"`
+}
+
+func TestDo(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/articles/1":
+			w.Write([]byte(`{"id":1,"title":"hello"}`))
+		case "/broken":
+			w.Write([]byte(`{"id":`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`not found`))
+		}
+	}))
+	defer srv.Close()
+
+	NewClient(&Config{Remotes: map[string][]string{"api": {srv.URL}}})
+
+	tests := []struct {
+		name   string
+		remote string
+		path   string
+		want   error
+		status int
+	}{
+		{"decoded", "api", "/articles/1", nil, http.StatusOK},
+		{"status", "api", "/articles/2", ErrStatus, http.StatusNotFound},
+		{"decode", "api", "/broken", ErrDecode, http.StatusOK},
+		{"unknown remote", "other", "/articles/1", ErrUnknownRemote, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, meta, err := Do[article](context.Background(), tt.remote, tt.path)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Do() = %v, want %v", err, tt.want)
+			}
+
+			if meta.Status != tt.status {
+				t.Fatalf("Status = %d, want %d", meta.Status, tt.status)
+			}
+
+			var statusErr *StatusError
+			if errors.As(err, &statusErr) && statusErr.Body != "not found" {
+				t.Fatalf("StatusError.Body = %q", statusErr.Body)
+			}
+
+			if tt.want == nil && (out.Id != 1 || out.Title != "hello") {
+				t.Fatalf("Do() = %+v", out)
+			}
+		})
+	}
+}
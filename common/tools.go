@@ -334,6 +334,8 @@ func (t *Tools) GetStatus(err error) string {
 	return "success"
 }
 
+// Deprecated: LoopCall calls every method without arguments through
+// reflection in undefined order, use server.RouteRegistrar instead.
 func (t *Tools) LoopCall(structs ...interface{}) {
 	for _, v := range structs {
 		classType := reflect.TypeOf(v)
@@ -341,7 +343,7 @@ func (t *Tools) LoopCall(structs ...interface{}) {
 
 		for i := 0; i < classType.NumMethod(); i++ {
 			m := classValue.MethodByName(classType.Method(i).Name)
-			if m.IsValid() {
+			if m.IsValid() && m.Type().NumIn() == 0 {
 				var params []reflect.Value
 				m.Call(params)
 			}
@@ -587,6 +589,13 @@ func (t *Tools) Do(attempts int) time.Duration {
 	return time.Duration(math.Pow(float64(attempts), math.E)) * time.Millisecond * 100
 }
 
+// DoJitter picks a random backoff between half and all of Do(attempts).
+func (t *Tools) DoJitter(attempts int) time.Duration {
+	d := t.Do(attempts)
+
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
 func (t *Tools) TimeNowUTC() time.Time {
 	return time.Now().UTC().Round(time.Millisecond)
 }
@@ -8,6 +8,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/nicksnyder/go-i18n/v2/i18n"
 	"github.com/shopastro/go-common/common"
+	"github.com/shopastro/go-common/errcode"
 	"github.com/shopastro/logs"
 	"go.uber.org/zap"
 )
@@ -30,6 +31,10 @@ type (
 const (
 	DewuCode           = "DEWU_CODE"
 	DewuReleaseVersion = "DEWU_RELEASE_VERSION"
+	// LocalizerName holds the request's *i18n.Localizer in the gin context.
+	LocalizerName = "Localizer"
+	// ParamsCode is the Code/Status of ParamsException.
+	ParamsCode = 900
 )
 
 func NewController(ctl *Controller) *Controller {
@@ -49,7 +54,7 @@ func (ctl *Controller) Response(ctx *gin.Context, data interface{}) {
 }
 
 func (ctl *Controller) ParamsException(ctx *gin.Context, err error) {
-	ctx.Set(DewuCode, 900)
+	ctx.Set(DewuCode, ParamsCode)
 
 	logs.Logger.Error("[ParamsException]",
 		zap.String("uri", ctx.Request.URL.Path),
@@ -57,9 +62,9 @@ func (ctl *Controller) ParamsException(ctx *gin.Context, err error) {
 
 	ctx.JSON(http.StatusOK, Response{
 		TraceId: common.NewRequest().TraceId(ctx),
-		Code:    900,
-		Status:  900,
-		Msg:     ctl.getLocalize(ctx).i18nLocalize(900),
+		Code:    ParamsCode,
+		Status:  ParamsCode,
+		Msg:     ctl.getLocalize(ctx).i18nLocalize(ParamsCode),
 		Data:    nil,
 	})
 }
@@ -101,6 +106,49 @@ func (ctl *Controller) ServiceCodeException(ctx *gin.Context, status int, err er
 	ctx.JSON(http.StatusOK, resp)
 }
 
+// Error renders an errcode.AppError with its code, HTTP status and localized
+// message, any other error as ServiceException.
+func (ctl *Controller) Error(ctx *gin.Context, err error) {
+	appErr, ok := errcode.FromError(err)
+	if !ok {
+		ctl.ServiceException(ctx, err)
+		return
+	}
+
+	logs.Logger.Error("[AppError]",
+		zap.String("uri", ctx.Request.URL.Path),
+		zap.Int("code", appErr.Code.Code),
+		zap.Error(err))
+
+	var localizer *i18n.Localizer
+	if l, ok := ctx.Get(LocalizerName); ok && l != nil {
+		localizer = l.(*i18n.Localizer)
+	}
+
+	ctx.Set(DewuCode, appErr.Code.Code)
+	ctx.JSON(appErr.Code.Status(), Response{
+		TraceId: common.NewRequest().TraceId(ctx),
+		Code:    appErr.Code.Code,
+		Status:  appErr.Code.Code,
+		Msg:     appErr.Code.Localize(localizer, appErr.Args...),
+		Data:    appErr.Data,
+	})
+}
+
+// Handle adapts a handler returning data or an error to gin, see Response
+// and Error.
+func (ctl *Controller) Handle(handler func(ctx *gin.Context) (interface{}, error)) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		data, err := handler(ctx)
+		if err != nil {
+			ctl.Error(ctx, err)
+			return
+		}
+
+		ctl.Response(ctx, data)
+	}
+}
+
 func (ctl *Controller) UnauthorizedException(ctx *gin.Context) {
 	ctx.Set(DewuCode, http.StatusUnauthorized)
 
@@ -147,18 +195,22 @@ func (ctl *Controller) Health(ctx *gin.Context) {
 }
 
 func (ctl *Controller) i18nLocalize(status int) string {
+	other := "Internal error in the service"
+	if code, ok := errcode.Lookup(status); ok {
+		other = code.Message
+	}
 
 	return ctl.localizer.MustLocalize(&i18n.LocalizeConfig{
 		MessageID: strconv.Itoa(status),
 		DefaultMessage: &i18n.Message{
 			ID:    strconv.Itoa(status),
-			Other: "Internal error in the service",
+			Other: other,
 		},
 	})
 }
 
 func (ctl *Controller) getLocalize(ctx *gin.Context) *Controller {
-	localizer, ok := ctx.Get("Localizer")
+	localizer, ok := ctx.Get(LocalizerName)
 	if ok && localizer != nil {
 		ctl.localizer = localizer.(*i18n.Localizer)
 	}
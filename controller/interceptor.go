@@ -19,13 +19,22 @@ type (
 const (
 	TokenName          = "X-Auth-Token"
 	StandardClaimsName = "standardClaims"
+	UserInfoName       = "userInfo"
 	BearerTokenPrefix  = "Bearer "
 	PoizonUuid         = "POIZON-UUID"
 	PoizonUserId       = "POIZON-USERID"
 	PoizonIsGuest      = "POIZON-ISGUEST"
 )
 
+// GetTokenInfo prefers the identity of a verified token, see
+// server.JWTHandler, over the POIZON-* headers.
 func (ctl *Controller) GetTokenInfo(ctx *gin.Context) UserInfoHeader {
+	if info, ok := ctx.Get(UserInfoName); ok {
+		if user, ok := info.(UserInfoHeader); ok {
+			return user
+		}
+	}
+
 	userId, err := strconv.ParseInt(ctx.GetHeader(PoizonUserId), 10, 64)
 	if err != nil {
 		logs.Logger.Error("[GetTokenInfo]",
@@ -0,0 +1,19 @@
+package errcode
+
+import (
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+)
+
+// Codes used by controller, their default messages are English.
+var (
+	OK              = New(http.StatusOK, http.StatusOK, codes.OK, "Success").Translate("zh", "成功")
+	Params          = New(900, http.StatusOK, codes.InvalidArgument, "Invalid parameters").Translate("zh", "参数错误")
+	Internal        = New(http.StatusInternalServerError, http.StatusOK, codes.Internal, "Internal error in the service").Translate("zh", "服务内部错误")
+	Unauthorized    = New(http.StatusUnauthorized, http.StatusUnauthorized, codes.Unauthenticated, "Unauthorized").Translate("zh", "未授权")
+	Forbidden       = New(http.StatusForbidden, http.StatusForbidden, codes.PermissionDenied, "Forbidden").Translate("zh", "禁止访问")
+	TooManyRequests = New(http.StatusTooManyRequests, http.StatusTooManyRequests, codes.ResourceExhausted, "Too many requests").Translate("zh", "请求过于频繁")
+	NeedLogin       = New(700, http.StatusUnauthorized, codes.Unauthenticated, "Please log in").Translate("zh", "请先登录")
+	GuestLogin      = New(7999, http.StatusUnauthorized, codes.Unauthenticated, "Guests please log in").Translate("zh", "游客请登录")
+)
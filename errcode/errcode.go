@@ -0,0 +1,229 @@
+package errcode
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type (
+	// Code is the Code/Status of controller.Response. Its i18n message id is
+	// the code itself, Message is the default and Translations are keyed by
+	// language tag. A zero HTTPStatus renders as 200, like the controller.
+	Code struct {
+		Code         int
+		HTTPStatus   int
+		GrpcCode     codes.Code
+		Message      string
+		Translations map[string]string
+	}
+
+	// AppError is a Code raised with message args, an optional cause and
+	// optional response data.
+	AppError struct {
+		Code *Code
+		Args []interface{}
+		Err  error
+		Data interface{}
+	}
+)
+
+// Domain marks the errdetails.ErrorInfo carrying the code in gRPC statuses.
+const Domain = "errcode"
+
+var (
+	mu       sync.RWMutex
+	registry = make(map[int]*Code)
+)
+
+// New registers a code; register codes before the server runs so their
+// translations reach the I18nBundle.
+func New(code, httpStatus int, grpcCode codes.Code, message string) *Code {
+	c := &Code{
+		Code:         code,
+		HTTPStatus:   httpStatus,
+		GrpcCode:     grpcCode,
+		Message:      message,
+		Translations: make(map[string]string),
+	}
+
+	Register(c)
+	return c
+}
+
+func Register(cs ...*Code) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	for _, c := range cs {
+		if _, ok := registry[c.Code]; ok {
+			panic(fmt.Sprintf("errcode: code %d registered twice", c.Code))
+		}
+
+		registry[c.Code] = c
+	}
+}
+
+func Lookup(code int) (*Code, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	c, ok := registry[code]
+	return c, ok
+}
+
+// All lists the registered codes in order.
+func All() []*Code {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	list := make([]*Code, 0, len(registry))
+	for _, c := range registry {
+		list = append(list, c)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Code < list[j].Code
+	})
+
+	return list
+}
+
+// Translate adds the message for lang, e.g. "en".
+func (c *Code) Translate(lang, message string) *Code {
+	mu.Lock()
+	c.Translations[lang] = message
+	mu.Unlock()
+
+	return c
+}
+
+func (c *Code) ID() string {
+	return strconv.Itoa(c.Code)
+}
+
+func (c *Code) Error() string {
+	return fmt.Sprintf("[%d] %s", c.Code, c.Message)
+}
+
+func (c *Code) Status() int {
+	if c.HTTPStatus == 0 {
+		return http.StatusOK
+	}
+
+	return c.HTTPStatus
+}
+
+// Localize formats the message of l's language with args, l may be nil.
+func (c *Code) Localize(l *i18n.Localizer, args ...interface{}) string {
+	msg := c.Message
+	if l != nil {
+		if m, err := l.Localize(&i18n.LocalizeConfig{
+			MessageID:      c.ID(),
+			DefaultMessage: &i18n.Message{ID: c.ID(), Other: c.Message},
+		}); err == nil {
+			msg = m
+		}
+	}
+
+	if len(args) > 0 {
+		msg = fmt.Sprintf(msg, args...)
+	}
+
+	return msg
+}
+
+func (c *Code) New(args ...interface{}) *AppError {
+	return &AppError{Code: c, Args: args}
+}
+
+func (c *Code) Wrap(err error, args ...interface{}) *AppError {
+	return &AppError{Code: c, Args: args, Err: err}
+}
+
+func (e *AppError) WithData(data interface{}) *AppError {
+	e.Data = data
+	return e
+}
+
+func (e *AppError) Error() string {
+	msg := e.Code.Localize(nil, e.Args...)
+	if e.Err != nil {
+		return fmt.Sprintf("[%d] %s: %v", e.Code.Code, msg, e.Err)
+	}
+
+	return fmt.Sprintf("[%d] %s", e.Code.Code, msg)
+}
+
+func (e *AppError) Unwrap() error {
+	return e.Err
+}
+
+// Is matches errors of the same code, so errors.Is(err, SomeCode) works.
+func (e *AppError) Is(target error) bool {
+	switch t := target.(type) {
+	case *Code:
+		return e.Code.Code == t.Code
+	case *AppError:
+		return e.Code.Code == t.Code.Code
+	}
+
+	return false
+}
+
+// GRPCStatus lets grpc return AppError as is, with the default message.
+func (e *AppError) GRPCStatus() *status.Status {
+	return e.LocalizedStatus(nil)
+}
+
+// LocalizedStatus carries the code in an errdetails.ErrorInfo.
+func (e *AppError) LocalizedStatus(l *i18n.Localizer) *status.Status {
+	st := status.New(e.Code.GrpcCode, e.Code.Localize(l, e.Args...))
+	if detailed, err := st.WithDetails(&errdetails.ErrorInfo{Reason: e.Code.ID(), Domain: Domain}); err == nil {
+		return detailed
+	}
+
+	return st
+}
+
+// FromError finds the AppError in err, a bare *Code counts as one.
+func FromError(err error) (*AppError, bool) {
+	var appErr *AppError
+	if errors.As(err, &appErr) {
+		return appErr, true
+	}
+
+	var code *Code
+	if errors.As(err, &code) {
+		return code.New(), true
+	}
+
+	return nil, false
+}
+
+// FromStatus returns the registered code carried by st, see LocalizedStatus.
+func FromStatus(st *status.Status) (*Code, bool) {
+	for _, d := range st.Details() {
+		info, ok := d.(*errdetails.ErrorInfo)
+		if !ok || info.Domain != Domain {
+			continue
+		}
+
+		code, err := strconv.Atoi(info.Reason)
+		if err != nil {
+			return nil, false
+		}
+
+		return Lookup(code)
+	}
+
+	return nil, false
+}
@@ -0,0 +1,73 @@
+package errcode
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"github.com/pelletier/go-toml/v2"
+	"golang.org/x/text/language"
+	"gopkg.in/yaml.v2"
+)
+
+// AddMessages puts the default message of every registered code into bundle
+// as English, together with its translations.
+func AddMessages(bundle *i18n.Bundle) error {
+	for _, c := range All() {
+		if err := bundle.AddMessages(language.English, &i18n.Message{ID: c.ID(), Other: c.Message}); err != nil {
+			return err
+		}
+
+		mu.RLock()
+		translations := make(map[string]string, len(c.Translations))
+		for lang, msg := range c.Translations {
+			translations[lang] = msg
+		}
+		mu.RUnlock()
+
+		for lang, msg := range translations {
+			tag, err := language.Parse(lang)
+			if err != nil {
+				return err
+			}
+
+			if err := bundle.AddMessages(tag, &i18n.Message{ID: c.ID(), Other: msg}); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// LoadTranslations loads every .toml, .yaml, .yml and .json file in dir, the
+// language comes from the file name, e.g. en.toml or active.zh-CN.yaml.
+func LoadTranslations(bundle *i18n.Bundle, dir string) error {
+	bundle.RegisterUnmarshalFunc("toml", toml.Unmarshal)
+	bundle.RegisterUnmarshalFunc("yaml", yaml.Unmarshal)
+	bundle.RegisterUnmarshalFunc("yml", yaml.Unmarshal)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		switch strings.ToLower(filepath.Ext(e.Name())) {
+		case ".toml", ".yaml", ".yml", ".json":
+		default:
+			continue
+		}
+
+		if e.IsDir() {
+			continue
+		}
+
+		if _, err := bundle.LoadMessageFile(filepath.Join(dir, e.Name())); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -5,6 +5,8 @@ import (
 	"time"
 
 	"github.com/go-redis/redis/v8"
+	"github.com/shopastro/go-common/health"
+	"github.com/shopastro/go-common/shutdown"
 	"github.com/shopastro/logs"
 	"go.uber.org/zap"
 	"golang.org/x/net/context"
@@ -55,6 +57,11 @@ func NewClusterClient(cfg *GoRedisConfig) {
 		ReadTimeout:  cfg.ReadTimeout * time.Millisecond,
 		WriteTimeout: cfg.ReadTimeout * time.Millisecond,
 	})
+
+	shutdown.Register("redis", func(context.Context) error { return Close() })
+	health.Register("redis-cluster", health.CheckerFunc(func(ctx context.Context) error {
+		return clusterClient.Ping(ctx).Err()
+	}))
 }
 
 func NewRedisClient(cfg *GoRedisConfig) *redis.Client {
@@ -92,5 +99,25 @@ func NewRedisClient(cfg *GoRedisConfig) *redis.Client {
 		logs.Logger.Fatal("[NewRedisClient]  error", zap.Error(err))
 	}
 
+	shutdown.Register("redis", func(context.Context) error { return Close() })
+	health.Register("redis", health.CheckerFunc(func(ctx context.Context) error {
+		return client.Ping(ctx).Err()
+	}))
+
 	return client
 }
+
+func Close() error {
+	var err error
+	if client != nil {
+		err = client.Close()
+	}
+
+	if clusterClient != nil {
+		if cerr := clusterClient.Close(); cerr != nil {
+			err = cerr
+		}
+	}
+
+	return err
+}
@@ -18,8 +18,6 @@ import (
 	"moul.io/http2curl"
 	"net/http"
 	"net/http/cookiejar"
-	"net/http/httputil"
-	"net/textproto"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -79,6 +77,9 @@ type SuperAgent struct {
 	FileData             []File
 	BounceToRawString    bool
 	RawString            string
+	BodyReader           io.Reader
+	BodyType             string
+	MaxBodySize          int64
 	Client               *http.Client
 	Transport            *http.Transport
 	Cookies              []*http.Cookie
@@ -90,6 +91,9 @@ type SuperAgent struct {
 	Retryable            superAgentRetryable
 	DoNotClearSuperAgent bool
 	isClone              bool
+	sharedTransport      bool
+	middlewares          []Middleware
+	streamResponse       bool
 	mu                   *sync.Mutex
 	context              context.Context
 	Tracer               opentracing.Tracer
@@ -119,7 +123,7 @@ func New() *SuperAgent {
 		FileData:          make([]File, 0),
 		BounceToRawString: false,
 		Client:            &http.Client{Jar: jar},
-		Transport:         &http.Transport{},
+		Transport:         DefaultTransport,
 		Cookies:           make([]*http.Cookie, 0),
 		Errors:            nil,
 		BasicAuth:         struct{ Username, Password string }{},
@@ -127,10 +131,9 @@ func New() *SuperAgent {
 		CurlCommand:       false,
 		logger:            log.New(os.Stderr, "[gorequest]", log.LstdFlags),
 		isClone:           false,
+		sharedTransport:   true,
 		mu:                new(sync.Mutex),
 	}
-	// disable keep alives by default, see this issue https://github.com/parnurzeal/gorequest/issues/75
-	s.Transport.DisableKeepAlives = true
 
 	return s
 }
@@ -229,6 +232,9 @@ func (s *SuperAgent) Clone() *SuperAgent {
 		FileData:             shallowCopyFileArray(s.FileData),
 		BounceToRawString:    s.BounceToRawString,
 		RawString:            s.RawString,
+		BodyReader:           s.BodyReader,
+		BodyType:             s.BodyType,
+		MaxBodySize:          s.MaxBodySize,
 		Client:               s.Client,
 		Transport:            s.Transport,
 		Cookies:              shallowCopyCookies(s.Cookies),
@@ -240,6 +246,8 @@ func (s *SuperAgent) Clone() *SuperAgent {
 		Retryable:            copyRetryable(s.Retryable),
 		DoNotClearSuperAgent: true,
 		isClone:              true,
+		sharedTransport:      s.sharedTransport,
+		middlewares:          append([]Middleware(nil), s.middlewares...),
 		context:              s.context,
 	}
 	return clone
@@ -269,6 +277,15 @@ func (s *SuperAgent) SetGinContext(context context.Context) *SuperAgent {
 	return s
 }
 
+// WithContext attaches ctx to every request, so cancellation and deadlines
+// abort in-flight requests and pending retries. The resulting errors match
+// ctx.Err() with errors.Is.
+func (s *SuperAgent) WithContext(ctx context.Context) *SuperAgent {
+	s.context = ctx
+
+	return s
+}
+
 func (s *SuperAgent) SetTrace(tr opentracing.Tracer) *SuperAgent {
 	s.Tracer = tr
 	return s
@@ -294,6 +311,8 @@ func (s *SuperAgent) ClearSuperAgent() {
 	s.FileData = make([]File, 0)
 	s.BounceToRawString = false
 	s.RawString = ""
+	s.BodyReader = nil
+	s.BodyType = ""
 	s.ForceType = ""
 	s.TargetType = TypeJSON
 	s.Cookies = make([]*http.Cookie, 0)
@@ -624,8 +643,9 @@ func (s *SuperAgent) Param(key string, value string) *SuperAgent {
 //        End()
 //
 func (s *SuperAgent) TLSClientConfig(config *tls.Config) *SuperAgent {
-	s.safeModifyTransport()
-	s.Transport.TLSClientConfig = config
+	s.modifyTransport(tlsKey{config}, func(t *http.Transport) {
+		t.TLSClientConfig = config
+	})
 	return s
 }
 
@@ -650,11 +670,13 @@ func (s *SuperAgent) Proxy(proxyUrl string) *SuperAgent {
 	if err != nil {
 		s.Errors = append(s.Errors, err)
 	} else if proxyUrl == "" {
-		s.safeModifyTransport()
-		s.Transport.Proxy = nil
+		s.modifyTransport(proxyKey(proxyUrl), func(t *http.Transport) {
+			t.Proxy = nil
+		})
 	} else {
-		s.safeModifyTransport()
-		s.Transport.Proxy = http.ProxyURL(parsedProxyUrl)
+		s.modifyTransport(proxyKey(proxyUrl), func(t *http.Transport) {
+			t.Proxy = http.ProxyURL(parsedProxyUrl)
+		})
 	}
 	return s
 }
@@ -862,6 +884,8 @@ type File struct {
 	Filename  string
 	Fieldname string
 	Data      []byte
+	// Reader is streamed instead of Data when set, see SendFileReader.
+	Reader io.Reader
 }
 
 // SendFile function works only with type "multipart". The function accepts one mandatory and up to two optional arguments. The mandatory (first) argument is the file.
@@ -1105,6 +1129,11 @@ func (s *SuperAgent) End(callback ...func(response Response, body string, errs [
 	return resp, bodyString, errs
 }
 
+// EndContext is End bound to ctx, see WithContext.
+func (s *SuperAgent) EndContext(ctx context.Context, callback ...func(response Response, body string, errs []error)) (Response, string, []error) {
+	return s.WithContext(ctx).End(callback...)
+}
+
 // EndBytes should be used when you want the body as bytes. The callbacks work the same way as with `End`, except that a byte array is used instead of a string.
 func (s *SuperAgent) EndBytes(callback ...func(response Response, body []byte, errs []error)) (Response, []byte, []error) {
 	var (
@@ -1124,6 +1153,11 @@ func (s *SuperAgent) EndBytes(callback ...func(response Response, body []byte, e
 		}
 	}
 
+	// retries cut short by the context
+	if len(s.Errors) != 0 {
+		return resp, body, s.Errors
+	}
+
 	respCallback := *resp
 	if len(callback) != 0 {
 		callback[0](&respCallback, body, s.Errors)
@@ -1133,13 +1167,34 @@ func (s *SuperAgent) EndBytes(callback ...func(response Response, body []byte, e
 
 func (s *SuperAgent) isRetryableRequest(resp Response) bool {
 	if s.Retryable.Enable && s.Retryable.Attempt < s.Retryable.RetryerCount && contains(resp.StatusCode, s.Retryable.RetryableStatus) {
-		time.Sleep(s.Retryable.RetryerTime)
+		if !s.sleep(s.Retryable.RetryerTime) {
+			s.Errors = append(s.Errors, s.context.Err())
+			return true
+		}
 		s.Retryable.Attempt++
 		return false
 	}
 	return true
 }
 
+// sleep waits for d and reports false when the context ends first.
+func (s *SuperAgent) sleep(d time.Duration) bool {
+	if s.context == nil || s.context.Done() == nil {
+		time.Sleep(d)
+		return true
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-s.context.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
 func contains(respStatus int, statuses []int) bool {
 	for _, status := range statuses {
 		if status == respStatus {
@@ -1168,6 +1223,24 @@ func (s *SuperAgent) EndStruct(v interface{}, callback ...func(response Response
 }
 
 func (s *SuperAgent) getResponseBytes() (Response, []byte, []error) {
+	resp, errs := s.getResponse()
+	if errs != nil {
+		return nil, nil, errs
+	}
+	defer resp.Body.Close()
+
+	body, err := s.readBody(resp.Body)
+	// Reset resp.Body so it can be use again
+	resp.Body = ioutil.NopCloser(bytes.NewBuffer(body))
+	if err != nil {
+		return nil, nil, []error{err}
+	}
+	return resp, body, nil
+}
+
+// getResponse sends the request and returns the response with its body
+// unread, the caller has to close it.
+func (s *SuperAgent) getResponse() (Response, []error) {
 	var (
 		req  *http.Request
 		err  error
@@ -1175,7 +1248,7 @@ func (s *SuperAgent) getResponseBytes() (Response, []byte, []error) {
 	)
 	// check whether there is an error. if yes, return all errors
 	if len(s.Errors) != 0 {
-		return nil, nil, s.Errors
+		return nil, s.Errors
 	}
 	// check if there is forced type
 	switch s.ForceType {
@@ -1201,7 +1274,7 @@ func (s *SuperAgent) getResponseBytes() (Response, []byte, []error) {
 	req, err = s.MakeRequest()
 	if err != nil {
 		s.Errors = append(s.Errors, err)
-		return nil, nil, s.Errors
+		return nil, s.Errors
 	}
 
 	// Set Transport
@@ -1209,67 +1282,30 @@ func (s *SuperAgent) getResponseBytes() (Response, []byte, []error) {
 		s.Client.Transport = s.Transport
 	}
 
-	// Log details of this request
-	if s.Debug {
-		dump, err := httputil.DumpRequest(req, true)
-		s.logger.SetPrefix("[http] ")
-		if err != nil {
-			s.logger.Println("Error:", err)
-		} else {
-			s.logger.Printf("HTTP Request: %s", string(dump))
-		}
-	}
-
-	// Display CURL command line
-	if s.CurlCommand {
-		curl, err := http2curl.GetCurlCommand(req)
-		s.logger.SetPrefix("[curl] ")
-		if err != nil {
-			s.logger.Println("Error:", err)
-		} else {
-			s.logger.Printf("CURL command line: %s", curl)
-		}
-	}
-
-	// tracer request
-	if span := s.clientTracer(req); span != nil {
-		defer span.Finish()
-	}
-
-	// Send request
-	resp, err = s.Client.Do(req)
+	// Send request through the middleware chain
+	resp, err = s.roundTripper().RoundTrip(req)
 	if err != nil {
 		s.Errors = append(s.Errors, err)
-		return nil, nil, s.Errors
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= http.StatusInternalServerError {
-		if span := opentracing.SpanFromContext(s.context); span != nil {
-			ext.Error.Set(span, true)
-		}
+		return nil, s.Errors
 	}
+	return resp, nil
+}
 
-	// Log details of this response
-	if s.Debug {
-		dump, err := httputil.DumpResponse(resp, true)
-		if nil != err {
-			s.logger.Println("Error:", err)
-		} else {
-			s.logger.Printf("HTTP Response: %s", string(dump))
-		}
+func (s *SuperAgent) newRequest(body io.Reader) (*http.Request, error) {
+	if s.context == nil {
+		return http.NewRequest(s.Method, s.Url, body)
 	}
 
-	body, err := ioutil.ReadAll(resp.Body)
-	// Reset resp.Body so it can be use again
-	resp.Body = ioutil.NopCloser(bytes.NewBuffer(body))
-	if err != nil {
-		return nil, nil, []error{err}
-	}
-	return resp, body, nil
+	return http.NewRequestWithContext(s.context, s.Method, s.Url, body)
 }
 
 func (s *SuperAgent) MakeRequest() (*http.Request, error) {
+	return s.makeRequest(true)
+}
+
+// makeRequest leaves out streamed bodies unless stream is set, so they are
+// neither consumed nor started.
+func (s *SuperAgent) makeRequest(stream bool) (*http.Request, error) {
 	var (
 		req           *http.Request
 		contentType   string // This is only set when the request body content is non-empty.
@@ -1293,7 +1329,12 @@ func (s *SuperAgent) MakeRequest() (*http.Request, error) {
 	//
 	//     https://github.com/parnurzeal/gorequest/pull/136
 	//
-	switch s.TargetType {
+	targetType := s.TargetType
+	if s.BodyReader != nil {
+		targetType = typeStream
+	}
+
+	switch targetType {
 	case TypeJSON:
 		// If-case to give support to json array. we check if
 		// 1) Map only: send it as json map from s.Data
@@ -1333,72 +1374,41 @@ func (s *SuperAgent) MakeRequest() (*http.Request, error) {
 			contentType = "application/xml"
 		}
 	case TypeMultipart:
+		if s.hasFileReaders() {
+			if stream {
+				contentReader, contentType = s.multipartStream()
+			}
+			break
+		}
+
 		var (
 			buf = &bytes.Buffer{}
 			mw  = multipart.NewWriter(buf)
 		)
 
-		if s.BounceToRawString {
-			fieldName := s.Header.Get("data_fieldname")
-			if fieldName == "" {
-				fieldName = "data"
-			}
-			fw, _ := mw.CreateFormField(fieldName)
-			fw.Write([]byte(s.RawString))
-			contentReader = buf
-		}
-
-		if len(s.Data) != 0 {
-			formData := changeMapToURLValues(s.Data)
-			for key, values := range formData {
-				for _, value := range values {
-					fw, _ := mw.CreateFormField(key)
-					fw.Write([]byte(value))
-				}
-			}
-			contentReader = buf
-		}
-
-		if len(s.SliceData) != 0 {
-			fieldName := s.Header.Get("json_fieldname")
-			if fieldName == "" {
-				fieldName = "data"
-			}
-			// copied from CreateFormField() in mime/multipart/writer.go
-			h := make(textproto.MIMEHeader)
-			fieldName = strings.Replace(strings.Replace(fieldName, "\\", "\\\\", -1), `"`, "\\\"", -1)
-			h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"`, fieldName))
-			h.Set("Content-Type", "application/json")
-			fw, _ := mw.CreatePart(h)
-			contentJson, err := json.Marshal(s.SliceData)
-			if err != nil {
-				return nil, err
-			}
-			fw.Write(contentJson)
-			contentReader = buf
-		}
-
-		// add the files
-		if len(s.FileData) != 0 {
-			for _, file := range s.FileData {
-				fw, _ := mw.CreateFormFile(file.Fieldname, file.Filename)
-				fw.Write(file.Data)
-			}
-			contentReader = buf
+		written, err := s.writeMultipart(mw)
+		if err != nil {
+			return nil, err
 		}
 
 		// close before call to FormDataContentType ! otherwise its not valid multipart
 		mw.Close()
 
-		if contentReader != nil {
+		if written {
+			contentReader = buf
 			contentType = mw.FormDataContentType()
 		}
+	case typeStream:
+		if stream {
+			contentReader = s.BodyReader
+		}
+		contentType = s.BodyType
 	default:
 		// let's return an error instead of an nil pointer exception here
 		return nil, errors.New("TargetType '" + s.TargetType + "' could not be determined")
 	}
 
-	if req, err = http.NewRequest(s.Method, s.Url, contentReader); err != nil {
+	if req, err = s.newRequest(contentReader); err != nil {
 		return nil, err
 	}
 
@@ -1443,8 +1453,9 @@ func (s *SuperAgent) MakeRequest() (*http.Request, error) {
 
 // AsCurlCommand returns a string representing the runnable `curl' command
 // version of the request.
+// Streamed bodies are left out.
 func (s *SuperAgent) AsCurlCommand() (string, error) {
-	req, err := s.MakeRequest()
+	req, err := s.makeRequest(false)
 	if err != nil {
 		return "", err
 	}
@@ -1455,13 +1466,11 @@ func (s *SuperAgent) AsCurlCommand() (string, error) {
 	return cmd.String(), nil
 }
 
-func (s *SuperAgent) clientTracer(req *http.Request) opentracing.Span {
-	if s.context == nil {
-		return nil
+func (s *SuperAgent) clientTracer(req *http.Request) (*http.Request, opentracing.Span) {
+	if s.context == nil || s.Tracer == nil {
+		return req, nil
 	}
 
-	req.WithContext(s.context)
-
 	if span := opentracing.SpanFromContext(s.context); span != nil {
 		span := s.Tracer.StartSpan(fmt.Sprintf("HTTP %s: %s", s.Method, req.URL.Path), opentracing.ChildOf(span.Context()))
 
@@ -1478,8 +1487,8 @@ func (s *SuperAgent) clientTracer(req *http.Request) opentracing.Span {
 			s.logger.Println(err)
 		}
 
-		return span
+		return req.WithContext(s.context), span
 	}
 
-	return nil
+	return req, nil
 }
@@ -0,0 +1,13 @@
+//go:build go1.13
+// +build go1.13
+
+package gorequest
+
+// does a shallow clone of the transport
+func (s *SuperAgent) safeModifyTransport() {
+	if !s.isClone && !s.sharedTransport {
+		return
+	}
+	s.sharedTransport = false
+	s.Transport = s.Transport.Clone()
+}
@@ -9,9 +9,10 @@ import (
 
 // does a shallow clone of the transport
 func (s *SuperAgent) safeModifyTransport() {
-	if !s.isClone {
+	if !s.isClone && !s.sharedTransport {
 		return
 	}
+	s.sharedTransport = false
 	oldTransport := s.Transport
 	s.Transport = &http.Transport{
 		Proxy:                 oldTransport.Proxy,
@@ -0,0 +1,116 @@
+package gorequest
+
+import (
+	"net/http"
+	"net/http/httputil"
+
+	"github.com/opentracing/opentracing-go/ext"
+	"moul.io/http2curl"
+)
+
+type (
+	// Middleware wraps the RoundTripper that sends a request, e.g. to inject
+	// auth headers, sign requests, record metrics or serve from a cache.
+	Middleware func(next http.RoundTripper) http.RoundTripper
+
+	RoundTripperFunc func(req *http.Request) (*http.Response, error)
+)
+
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Use appends middlewares; the first one added sees the request first.
+// They wrap tracing, debug and curl logging, and survive ClearSuperAgent.
+func (s *SuperAgent) Use(middlewares ...Middleware) *SuperAgent {
+	s.middlewares = append(s.middlewares, middlewares...)
+	return s
+}
+
+// Chain composes middlewares around next, the first being outermost.
+func Chain(next http.RoundTripper, middlewares ...Middleware) http.RoundTripper {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		next = middlewares[i](next)
+	}
+
+	return next
+}
+
+func (s *SuperAgent) roundTripper() http.RoundTripper {
+	middlewares := append(append([]Middleware(nil), s.middlewares...), s.tracing, s.debug, s.curl)
+
+	return Chain(RoundTripperFunc(s.Client.Do), middlewares...)
+}
+
+func (s *SuperAgent) tracing(next http.RoundTripper) http.RoundTripper {
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		req, span := s.clientTracer(req)
+		if span == nil {
+			return next.RoundTrip(req)
+		}
+		defer span.Finish()
+
+		resp, err := next.RoundTrip(req)
+		if err != nil || resp.StatusCode >= http.StatusInternalServerError {
+			ext.Error.Set(span, true)
+		}
+
+		return resp, err
+	})
+}
+
+// debug logs details of the request and the response, without the bodies
+// of streams
+func (s *SuperAgent) debug(next http.RoundTripper) http.RoundTripper {
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if !s.Debug {
+			return next.RoundTrip(req)
+		}
+
+		dump, err := httputil.DumpRequest(req, !streamedBody(req))
+		s.logger.SetPrefix("[http] ")
+		if err != nil {
+			s.logger.Println("Error:", err)
+		} else {
+			s.logger.Printf("HTTP Request: %s", string(dump))
+		}
+
+		resp, err := next.RoundTrip(req)
+		if err != nil {
+			return resp, err
+		}
+
+		dump, err = httputil.DumpResponse(resp, !s.streamResponse)
+		if nil != err {
+			s.logger.Println("Error:", err)
+		} else {
+			s.logger.Printf("HTTP Response: %s", string(dump))
+		}
+
+		return resp, nil
+	})
+}
+
+// curl displays the CURL command line of the request, leaving out streamed
+// bodies
+func (s *SuperAgent) curl(next http.RoundTripper) http.RoundTripper {
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if s.CurlCommand {
+			cmdReq := req
+			if streamedBody(req) {
+				cmdReq = req.Clone(req.Context())
+				cmdReq.Body = nil
+			}
+
+			curl, err := http2curl.GetCurlCommand(cmdReq)
+			s.logger.SetPrefix("[curl] ")
+			if err != nil {
+				s.logger.Println("Error:", err)
+			} else {
+				s.logger.Printf("CURL command line: %s", curl)
+			}
+		}
+
+		return next.RoundTrip(req)
+	})
+}
@@ -0,0 +1,173 @@
+package gorequest
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// TransportConfig tunes a pooled Transport, durations are in milliseconds.
+type TransportConfig struct {
+	MaxIdleConns          int           `json:"maxIdleConns" yaml:"maxIdleConns"`
+	MaxIdleConnsPerHost   int           `json:"maxIdleConnsPerHost" yaml:"maxIdleConnsPerHost"`
+	MaxConnsPerHost       int           `json:"maxConnsPerHost" yaml:"maxConnsPerHost"`
+	IdleConnTimeout       time.Duration `json:"idleConnTimeout" yaml:"idleConnTimeout"`
+	DialTimeout           time.Duration `json:"dialTimeout" yaml:"dialTimeout"`
+	KeepAlive             time.Duration `json:"keepAlive" yaml:"keepAlive"`
+	TLSHandshakeTimeout   time.Duration `json:"tlsHandshakeTimeout" yaml:"tlsHandshakeTimeout"`
+	ResponseHeaderTimeout time.Duration `json:"responseHeaderTimeout" yaml:"responseHeaderTimeout"`
+	DisableHTTP2          bool          `json:"disableHttp2" yaml:"disableHttp2"`
+	InsecureSkipVerify    bool          `json:"insecureSkipVerify" yaml:"insecureSkipVerify"`
+}
+
+const (
+	MaxIdleConnsDefault        = 100
+	MaxIdleConnsPerHostDefault = 32
+	IdleConnTimeoutDefault     = 90 * time.Second
+	DialTimeoutDefault         = 3 * time.Second
+	KeepAliveDefault           = 30 * time.Second
+	TLSHandshakeTimeoutDefault = 5 * time.Second
+)
+
+type (
+	derivedKey struct {
+		base *http.Transport
+		key  interface{}
+	}
+
+	tlsKey struct {
+		config *tls.Config
+	}
+
+	proxyKey string
+)
+
+var (
+	transports   = make(map[string]*http.Transport)
+	derived      = make(map[derivedKey]*http.Transport)
+	transportsMu sync.Mutex
+
+	// DefaultTransport is used by SuperAgents from New, so their keep-alive
+	// connections are pooled.
+	DefaultTransport = NewTransport(nil)
+)
+
+// NewTransport builds a keep-alive Transport from cfg, nil uses the defaults.
+func NewTransport(cfg *TransportConfig) *http.Transport {
+	if cfg == nil {
+		cfg = &TransportConfig{}
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   millisOr(cfg.DialTimeout, DialTimeoutDefault),
+		KeepAlive: millisOr(cfg.KeepAlive, KeepAliveDefault),
+	}
+
+	t := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		MaxIdleConns:          intOr(cfg.MaxIdleConns, MaxIdleConnsDefault),
+		MaxIdleConnsPerHost:   intOr(cfg.MaxIdleConnsPerHost, MaxIdleConnsPerHostDefault),
+		MaxConnsPerHost:       cfg.MaxConnsPerHost,
+		IdleConnTimeout:       millisOr(cfg.IdleConnTimeout, IdleConnTimeoutDefault),
+		TLSHandshakeTimeout:   millisOr(cfg.TLSHandshakeTimeout, TLSHandshakeTimeoutDefault),
+		ResponseHeaderTimeout: cfg.ResponseHeaderTimeout * time.Millisecond,
+		ExpectContinueTimeout: time.Second,
+		ForceAttemptHTTP2:     !cfg.DisableHTTP2,
+	}
+
+	if cfg.InsecureSkipVerify {
+		t.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+
+	if cfg.DisableHTTP2 {
+		t.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+	}
+
+	return t
+}
+
+// SharedTransport returns the Transport registered under key, creating it
+// from cfg on first use so connections are pooled across SuperAgents.
+func SharedTransport(key string, cfg *TransportConfig) *http.Transport {
+	transportsMu.Lock()
+	defer transportsMu.Unlock()
+
+	if t, ok := transports[key]; ok {
+		return t
+	}
+
+	t := NewTransport(cfg)
+	transports[key] = t
+
+	return t
+}
+
+// ResetTransports drops the shared transports and closes their idle
+// connections, e.g. after the configuration changed.
+func ResetTransports() {
+	transportsMu.Lock()
+	old, oldDerived := transports, derived
+	transports = make(map[string]*http.Transport)
+	derived = make(map[derivedKey]*http.Transport)
+	transportsMu.Unlock()
+
+	for _, t := range old {
+		t.CloseIdleConnections()
+	}
+
+	for _, t := range oldDerived {
+		t.CloseIdleConnections()
+	}
+}
+
+// UseTransport makes s send through t. t is treated as shared: settings
+// such as TLSClientConfig or Proxy modify a copy instead of t.
+func (s *SuperAgent) UseTransport(t *http.Transport) *SuperAgent {
+	s.Transport = t
+	s.sharedTransport = true
+	return s
+}
+
+// modifyTransport applies modify to the transport of s. A shared transport
+// is left alone: s switches to a copy of it, reused by every SuperAgent
+// making the same change so connections stay pooled.
+func (s *SuperAgent) modifyTransport(key interface{}, modify func(t *http.Transport)) {
+	if !s.sharedTransport {
+		s.safeModifyTransport()
+		modify(s.Transport)
+		return
+	}
+
+	k := derivedKey{base: s.Transport, key: key}
+
+	transportsMu.Lock()
+	defer transportsMu.Unlock()
+
+	t, ok := derived[k]
+	if !ok {
+		t = s.Transport.Clone()
+		modify(t)
+		derived[k] = t
+	}
+
+	s.Transport = t
+}
+
+func millisOr(d, def time.Duration) time.Duration {
+	if d <= 0 {
+		return def
+	}
+
+	return d * time.Millisecond
+}
+
+func intOr(n, def int) int {
+	if n <= 0 {
+		return def
+	}
+
+	return n
+}
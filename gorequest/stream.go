@@ -0,0 +1,179 @@
+package gorequest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const typeStream = "stream"
+
+var ErrBodyTooLarge = errors.New("response body exceeds MaxBodySize")
+
+// SendStream uses r as the request body without buffering it. Such a body
+// can only be sent once, so it does not work together with Retry.
+func (s *SuperAgent) SendStream(r io.Reader, contentType string) *SuperAgent {
+	s.BodyReader = r
+	s.BodyType = contentType
+	return s
+}
+
+// SendFileReader adds a multipart file that is streamed from r. Like
+// SendStream, the body can only be sent once.
+func (s *SuperAgent) SendFileReader(r io.Reader, filename, fieldname string) *SuperAgent {
+	if fieldname == "" {
+		fieldname = "file" + strconv.Itoa(len(s.FileData)+1)
+	}
+
+	s.FileData = append(s.FileData, File{
+		Filename:  filename,
+		Fieldname: fieldname,
+		Reader:    r,
+	})
+	s.TargetType = TypeMultipart
+	return s
+}
+
+// SetMaxBodySize limits the response body read by End, EndBytes and
+// EndStruct; 0 means no limit.
+func (s *SuperAgent) SetMaxBodySize(n int64) *SuperAgent {
+	s.MaxBodySize = n
+	return s
+}
+
+// EndStream sends the request and hands back the response with its body
+// unread, e.g. for large downloads or server-sent events. The caller must
+// close the body. Retry does not apply.
+func (s *SuperAgent) EndStream() (*http.Response, []error) {
+	s.streamResponse = true
+	defer func() { s.streamResponse = false }()
+
+	resp, errs := s.getResponse()
+	if errs != nil {
+		return nil, errs
+	}
+
+	return resp, nil
+}
+
+func (s *SuperAgent) readBody(body io.Reader) ([]byte, error) {
+	if s.MaxBodySize <= 0 {
+		return ioutil.ReadAll(body)
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(body, s.MaxBodySize+1))
+	if err != nil {
+		return data, err
+	}
+
+	if int64(len(data)) > s.MaxBodySize {
+		return data[:s.MaxBodySize], ErrBodyTooLarge
+	}
+
+	return data, nil
+}
+
+// streamedBody reports a request body that can be read only once.
+func streamedBody(req *http.Request) bool {
+	return req.Body != nil && req.Body != http.NoBody && req.GetBody == nil
+}
+
+func (s *SuperAgent) hasFileReaders() bool {
+	for _, file := range s.FileData {
+		if file.Reader != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// multipartStream writes the multipart body through a pipe while the
+// request is being sent.
+func (s *SuperAgent) multipartStream() (io.Reader, string) {
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+
+	go func() {
+		_, err := s.writeMultipart(mw)
+		if err == nil {
+			err = mw.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+
+	return pr, mw.FormDataContentType()
+}
+
+// writeMultipart writes the data and files of s and reports whether any
+// part was written.
+func (s *SuperAgent) writeMultipart(mw *multipart.Writer) (bool, error) {
+	written := false
+
+	if s.BounceToRawString {
+		fieldName := s.Header.Get("data_fieldname")
+		if fieldName == "" {
+			fieldName = "data"
+		}
+		fw, _ := mw.CreateFormField(fieldName)
+		fw.Write([]byte(s.RawString))
+		written = true
+	}
+
+	if len(s.Data) != 0 {
+		formData := changeMapToURLValues(s.Data)
+		for key, values := range formData {
+			for _, value := range values {
+				fw, _ := mw.CreateFormField(key)
+				fw.Write([]byte(value))
+			}
+		}
+		written = true
+	}
+
+	if len(s.SliceData) != 0 {
+		fieldName := s.Header.Get("json_fieldname")
+		if fieldName == "" {
+			fieldName = "data"
+		}
+		// copied from CreateFormField() in mime/multipart/writer.go
+		h := make(textproto.MIMEHeader)
+		fieldName = strings.Replace(strings.Replace(fieldName, "\\", "\\\\", -1), `"`, "\\\"", -1)
+		h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"`, fieldName))
+		h.Set("Content-Type", "application/json")
+		fw, _ := mw.CreatePart(h)
+		contentJson, err := json.Marshal(s.SliceData)
+		if err != nil {
+			return written, err
+		}
+		fw.Write(contentJson)
+		written = true
+	}
+
+	// add the files
+	for _, file := range s.FileData {
+		fw, err := mw.CreateFormFile(file.Fieldname, file.Filename)
+		if err != nil {
+			return written, err
+		}
+
+		if file.Reader != nil {
+			if _, err := io.Copy(fw, file.Reader); err != nil {
+				return written, err
+			}
+		} else {
+			fw.Write(file.Data)
+		}
+		written = true
+	}
+
+	return written, nil
+}
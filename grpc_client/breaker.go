@@ -0,0 +1,330 @@
+package grpc_client
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/shopastro/logs"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type (
+	// BreakerConfig durations are in milliseconds. The breaker opens when
+	// ConsecutiveFailures is reached, or when the error rate within Window
+	// exceeds ErrorRate after at least MinRequests calls. Breakers are off
+	// unless a config is set for the target or as the default.
+	BreakerConfig struct {
+		Disabled            bool
+		ErrorRate           float64
+		MinRequests         int
+		ConsecutiveFailures int
+		Window              time.Duration
+		OpenTimeout         time.Duration
+		HalfOpenRequests    int
+	}
+
+	breakerState int
+
+	breakerOutcome int
+
+	breaker struct {
+		mu          sync.Mutex
+		cfg         *BreakerConfig
+		state       breakerState
+		openedAt    time.Time
+		windowStart time.Time
+		requests    int
+		failures    int
+		consecutive int
+		probes      int
+		successes   int
+		generation  uint64
+		gauge       prometheus.Gauge
+	}
+
+	breakerGroup struct {
+		mu       sync.RWMutex
+		def      *BreakerConfig
+		configs  map[string]*BreakerConfig
+		breakers map[string]*breaker
+	}
+)
+
+const (
+	breakerClosed breakerState = iota
+	breakerHalfOpen
+	breakerOpen
+)
+
+const (
+	breakerSuccess breakerOutcome = iota
+	breakerFailure
+	breakerIgnored
+)
+
+var (
+	breakerStateGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "grpc_client_circuit_breaker_state",
+		Help: "Circuit breaker state per target and method: 0 closed, 1 half-open, 2 open.",
+	}, []string{"target", "method"})
+
+	breakerGaugeOnce sync.Once
+
+	breakers = &breakerGroup{
+		configs:  make(map[string]*BreakerConfig),
+		breakers: make(map[string]*breaker),
+	}
+)
+
+// DefaultBreakerConfig returns the recommended settings for opting in.
+func DefaultBreakerConfig() *BreakerConfig {
+	return &BreakerConfig{
+		ErrorRate:           0.5,
+		MinRequests:         20,
+		ConsecutiveFailures: 5,
+		Window:              10000,
+		OpenTimeout:         5000,
+		HalfOpenRequests:    1,
+	}
+}
+
+// SetBreakerConfig enables the breaker of target with cfg; an empty target
+// sets the default for all targets. It applies to breakers created afterwards.
+func SetBreakerConfig(target string, cfg *BreakerConfig) {
+	breakers.mu.Lock()
+	defer breakers.mu.Unlock()
+
+	if target == "" {
+		breakers.def = cfg
+		return
+	}
+
+	breakers.configs[target] = cfg
+}
+
+func UnaryClientBreaker() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		b := breakers.get(cc.Target(), method)
+		if b == nil {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		gen, err := b.allow(method)
+		if err != nil {
+			return err
+		}
+
+		err = invoker(ctx, method, req, reply, cc, opts...)
+		b.done(gen, breakerResult(ctx, err))
+
+		return err
+	}
+}
+
+// StreamClientBreaker only accounts for establishing the stream.
+func StreamClientBreaker() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		b := breakers.get(cc.Target(), method)
+		if b == nil {
+			return streamer(ctx, desc, cc, method, opts...)
+		}
+
+		gen, err := b.allow(method)
+		if err != nil {
+			return nil, err
+		}
+
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		b.done(gen, breakerResult(ctx, err))
+
+		return cs, err
+	}
+}
+
+func (g *breakerGroup) get(target, method string) *breaker {
+	key := target + method
+
+	g.mu.RLock()
+	b, ok := g.breakers[key]
+	g.mu.RUnlock()
+	if ok {
+		return b
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if b, ok = g.breakers[key]; ok {
+		return b
+	}
+
+	cfg, ok := g.configs[target]
+	if !ok {
+		cfg = g.def
+	}
+
+	if cfg == nil || cfg.Disabled {
+		g.breakers[key] = nil
+		return nil
+	}
+
+	breakerGaugeOnce.Do(registerBreakerGauge)
+
+	b = &breaker{
+		cfg:         cfg,
+		windowStart: time.Now(),
+		gauge:       breakerStateGauge.WithLabelValues(target, method),
+	}
+	b.gauge.Set(float64(breakerClosed))
+	g.breakers[key] = b
+
+	return b
+}
+
+// allow returns the generation the call starts in, which changes with every
+// state transition.
+func (b *breaker) allow(method string) (uint64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == breakerOpen {
+		if time.Since(b.openedAt) < b.cfg.OpenTimeout*time.Millisecond {
+			return 0, status.Errorf(codes.Unavailable, "circuit breaker is open for %s", method)
+		}
+
+		b.setState(breakerHalfOpen)
+	}
+
+	if b.state == breakerHalfOpen {
+		if b.probes >= b.halfOpenRequests() {
+			return 0, status.Errorf(codes.Unavailable, "circuit breaker is half-open for %s", method)
+		}
+
+		b.probes++
+	}
+
+	return b.generation, nil
+}
+
+// done records the outcome of a call started in generation gen; results of
+// calls started before the last transition are dropped.
+func (b *breaker) done(gen uint64, outcome breakerOutcome) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if gen != b.generation {
+		return
+	}
+
+	failed := outcome == breakerFailure
+	if b.state == breakerHalfOpen {
+		if outcome == breakerIgnored {
+			// hand the probe slot to the next call
+			if b.probes > 0 {
+				b.probes--
+			}
+
+			return
+		}
+
+		if failed {
+			b.setState(breakerOpen)
+			return
+		}
+
+		b.successes++
+		if b.successes >= b.halfOpenRequests() {
+			b.setState(breakerClosed)
+		}
+
+		return
+	}
+
+	if b.state != breakerClosed || outcome == breakerIgnored {
+		return
+	}
+
+	if window := b.cfg.Window * time.Millisecond; window > 0 && time.Since(b.windowStart) > window {
+		b.windowStart = time.Now()
+		b.requests, b.failures = 0, 0
+	}
+
+	b.requests++
+	if !failed {
+		b.consecutive = 0
+		return
+	}
+
+	b.failures++
+	b.consecutive++
+
+	if b.cfg.ConsecutiveFailures > 0 && b.consecutive >= b.cfg.ConsecutiveFailures {
+		b.setState(breakerOpen)
+		return
+	}
+
+	if b.cfg.ErrorRate > 0 && b.requests >= b.cfg.MinRequests &&
+		float64(b.failures)/float64(b.requests) >= b.cfg.ErrorRate {
+		b.setState(breakerOpen)
+	}
+}
+
+func (b *breaker) setState(state breakerState) {
+	b.state = state
+	b.generation++
+	b.probes, b.successes = 0, 0
+
+	switch state {
+	case breakerOpen:
+		b.openedAt = time.Now()
+	case breakerClosed:
+		b.windowStart = time.Now()
+		b.requests, b.failures, b.consecutive = 0, 0, 0
+	}
+
+	b.gauge.Set(float64(state))
+}
+
+func (b *breaker) halfOpenRequests() int {
+	if b.cfg.HalfOpenRequests <= 0 {
+		return 1
+	}
+
+	return b.cfg.HalfOpenRequests
+}
+
+// breakerResult counts Unavailable and timeouts the caller did not cause as
+// failures. Calls cancelled or timed out by the caller count as neither.
+func breakerResult(ctx context.Context, err error) breakerOutcome {
+	if err == nil {
+		return breakerSuccess
+	}
+
+	switch status.Code(err) {
+	case codes.Canceled:
+		return breakerIgnored
+	case codes.DeadlineExceeded:
+		if ctx.Err() != nil {
+			return breakerIgnored
+		}
+
+		return breakerFailure
+	case codes.Unavailable:
+		return breakerFailure
+	default:
+		return breakerSuccess
+	}
+}
+
+func registerBreakerGauge() {
+	if err := prometheus.Register(breakerStateGauge); err != nil {
+		if _, ok := err.(prometheus.AlreadyRegisteredError); !ok {
+			logs.Logger.Error("[Prometheus registered]", zap.String("name", "grpc_client_circuit_breaker_state"), zap.Error(err))
+		}
+	}
+}
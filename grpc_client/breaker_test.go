@@ -0,0 +1,160 @@
+package grpc_client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newTestBreaker(cfg *BreakerConfig) *breaker {
+	return &breaker{
+		cfg:         cfg,
+		windowStart: time.Now(),
+		gauge:       breakerStateGauge.WithLabelValues("test", "/test.Service/Method"),
+	}
+}
+
+func TestBreakerResult(t *testing.T) {
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		err  error
+		want breakerOutcome
+	}{
+		{"ok", context.Background(), nil, breakerSuccess},
+		{"unavailable", context.Background(), status.Error(codes.Unavailable, ""), breakerFailure},
+		{"server timeout", context.Background(), status.Error(codes.DeadlineExceeded, ""), breakerFailure},
+		{"caller timeout", expired, status.Error(codes.DeadlineExceeded, ""), breakerIgnored},
+		{"canceled", context.Background(), status.Error(codes.Canceled, ""), breakerIgnored},
+		{"application error", context.Background(), status.Error(codes.NotFound, ""), breakerSuccess},
+		{"internal", context.Background(), status.Error(codes.Internal, ""), breakerSuccess},
+		{"non status", context.Background(), errors.New("boom"), breakerSuccess},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := breakerResult(tt.ctx, tt.err); got != tt.want {
+				t.Fatalf("breakerResult() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBreakerTransitions(t *testing.T) {
+	tests := []struct {
+		name     string
+		outcomes []breakerOutcome
+		want     breakerState
+	}{
+		{"closed on success", []breakerOutcome{breakerSuccess, breakerSuccess}, breakerClosed},
+		{"open on consecutive failures", []breakerOutcome{breakerFailure, breakerFailure}, breakerOpen},
+		{"success resets consecutive", []breakerOutcome{breakerFailure, breakerSuccess, breakerFailure}, breakerClosed},
+		{"ignored does not count", []breakerOutcome{breakerFailure, breakerIgnored, breakerIgnored}, breakerClosed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTestBreaker(&BreakerConfig{ConsecutiveFailures: 2, OpenTimeout: 10000})
+			for _, o := range tt.outcomes {
+				gen, err := b.allow("m")
+				if err != nil {
+					t.Fatalf("allow() = %v", err)
+				}
+				b.done(gen, o)
+			}
+
+			if b.state != tt.want {
+				t.Fatalf("state = %d, want %d", b.state, tt.want)
+			}
+		})
+	}
+}
+
+func TestBreakerHalfOpen(t *testing.T) {
+	tests := []struct {
+		name  string
+		probe breakerOutcome
+		want  breakerState
+	}{
+		{"probe success closes", breakerSuccess, breakerClosed},
+		{"probe failure reopens", breakerFailure, breakerOpen},
+		{"ignored probe stays half-open", breakerIgnored, breakerHalfOpen},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTestBreaker(&BreakerConfig{ConsecutiveFailures: 1, OpenTimeout: 1})
+
+			gen, _ := b.allow("m")
+			b.done(gen, breakerFailure)
+			if _, err := b.allow("m"); err == nil {
+				t.Fatal("allow() on open breaker = nil")
+			}
+
+			time.Sleep(5 * time.Millisecond)
+			gen, err := b.allow("m")
+			if err != nil {
+				t.Fatalf("allow() probe = %v", err)
+			}
+
+			if _, err := b.allow("m"); err == nil {
+				t.Fatal("allow() second probe = nil")
+			}
+
+			b.done(gen, tt.probe)
+			if b.state != tt.want {
+				t.Fatalf("state = %d, want %d", b.state, tt.want)
+			}
+
+			if tt.want == breakerHalfOpen {
+				if _, err := b.allow("m"); err != nil {
+					t.Fatalf("allow() after ignored probe = %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestBreakerStaleResults(t *testing.T) {
+	tests := []struct {
+		name  string
+		stale breakerOutcome
+	}{
+		{"stale success", breakerSuccess},
+		{"stale failure", breakerFailure},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTestBreaker(&BreakerConfig{ConsecutiveFailures: 1, OpenTimeout: 1})
+
+			// started while closed, finishes after the breaker went half-open
+			stale, _ := b.allow("m")
+			gen, _ := b.allow("m")
+			b.done(gen, breakerFailure)
+
+			time.Sleep(5 * time.Millisecond)
+			probe, err := b.allow("m")
+			if err != nil {
+				t.Fatalf("allow() probe = %v", err)
+			}
+
+			b.done(stale, tt.stale)
+			if b.state != breakerHalfOpen {
+				t.Fatalf("state after stale result = %d, want %d", b.state, breakerHalfOpen)
+			}
+
+			b.done(probe, breakerSuccess)
+			if b.state != breakerClosed {
+				t.Fatalf("state after probe = %d, want %d", b.state, breakerClosed)
+			}
+		})
+	}
+}
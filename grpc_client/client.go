@@ -1,15 +1,14 @@
 package grpc_client
 
 import (
-	"crypto/tls"
 	"fmt"
 	"time"
 
 	grpc_prometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
+	"github.com/shopastro/go-common/health"
 	"github.com/shopastro/logs"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/keepalive"
 )
 
@@ -20,10 +19,17 @@ type (
 	}
 
 	GrpcConfig struct {
-		Addr    string
-		Port    int
-		TimeOut time.Duration
-		Tls     bool
+		Addr               string
+		Port               int
+		TimeOut            time.Duration
+		Tls                bool
+		CAFile             string
+		CertFile           string
+		KeyFile            string
+		ServerName         string
+		InsecureSkipVerify bool
+		Methods            []*MethodConfig
+		Breaker            *BreakerConfig
 	}
 
 	ClientConn struct {
@@ -40,6 +46,8 @@ const (
 	grpcKeepAliveTime         = 10 * time.Second
 	grpcKeepAliveTimeout      = 3 * time.Second
 	BackoffMaxDelay           = 3 * time.Second
+	// TimeOutDefault bounds calls on conns dialed without a TimeOut.
+	TimeOutDefault = 3 * time.Second
 )
 
 var (
@@ -52,6 +60,10 @@ var (
 )
 
 func GetGrpcClient(key string) *ClientConn {
+	if clientSvr == nil {
+		return nil
+	}
+
 	c, ok := clientSvr.clientConn[key]
 	if !ok {
 		return nil
@@ -69,17 +81,28 @@ func NewGrpcClientService(router map[string]*GrpcConfig) *GrpcClientService {
 }
 
 func (svc *GrpcClientService) Dial() *GrpcClientService {
-	var secOpt grpc.DialOption
 	grpc_prometheus.EnableClientHandlingTimeHistogram()
 	for name, cfg := range svc.router {
-		if cfg.Tls {
-			secOpt = grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{
-				InsecureSkipVerify: cfg.Tls,
-			}))
-		} else {
-			secOpt = grpc.WithInsecure()
+		secOpt, err := cfg.securityOption()
+		if err != nil {
+			logs.Logger.Fatal("[GrpcClient TLS]", zap.String("name", name), zap.Error(err))
+		}
+
+		serviceCfg, err := getServiceConfig(cfg.Methods)
+		if err != nil {
+			logs.Logger.Fatal("[GrpcClient ServiceConfig]", zap.String("name", name), zap.Error(err))
+		}
+
+		target := fmt.Sprintf("%s:%d", cfg.Addr, cfg.Port)
+		if cfg.Breaker != nil {
+			SetBreakerConfig(target, cfg.Breaker)
 		}
-		clientConn, err := grpc.Dial(fmt.Sprintf("%s:%d", cfg.Addr, cfg.Port), append(ClientOpts(), secOpt)...)
+
+		clientConn, err := grpc.Dial(target, append(ClientOpts(),
+			secOpt,
+			grpc.WithDefaultServiceConfig(serviceCfg),
+			grpc.WithChainUnaryInterceptor(TimeoutUnaryClientInterceptor(methodTimeouts(cfg.Methods), cfg.TimeOut*time.Millisecond)),
+		)...)
 		if err != nil {
 			logs.Logger.Error("[GrpcClient Dial]", zap.Error(err))
 			continue
@@ -90,5 +113,7 @@ func (svc *GrpcClientService) Dial() *GrpcClientService {
 			TimeOut: cfg.TimeOut * time.Millisecond,
 		}
 	}
+
+	health.Register("grpc_client", health.CheckerFunc(CheckConnState))
 	return svc
 }
@@ -1,9 +1,13 @@
 package grpc_client
 
 import (
+	"time"
+
 	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
 	grpc_opentracing "github.com/grpc-ecosystem/go-grpc-middleware/tracing/opentracing"
 	grpc_prometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
+	"github.com/shopastro/go-common/health"
+	"github.com/shopastro/go-common/registry"
 	"github.com/shopastro/logs"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
@@ -11,8 +15,12 @@ import (
 
 type (
 	GrpcClientServiceEtcd struct {
-		router map[string]string
-		conn   map[string]*grpc.ClientConn
+		router    map[string]string
+		conn      map[string]*grpc.ClientConn
+		registry  registry.Registry
+		namespace string
+		timeout   time.Duration
+		methods   []*MethodConfig
 	}
 )
 
@@ -39,17 +47,55 @@ func NewGrpcClientServiceEtcd(router map[string]string) *GrpcClientServiceEtcd {
 	return conn
 }
 
+// SetRegistry resolves registry:///<name> targets in the router through r,
+// so connections follow instances as they come and go. Targets without a
+// namespace are looked up in namespace, the Config.Namespace of the servers.
+func (svc *GrpcClientServiceEtcd) SetRegistry(r registry.Registry, namespace string) *GrpcClientServiceEtcd {
+	svc.registry = r
+	svc.namespace = namespace
+
+	return svc
+}
+
+// SetTimeOut sets the deadline of calls made without one, in milliseconds,
+// and the per method timeouts; TimeOutDefault applies when timeout is zero.
+func (svc *GrpcClientServiceEtcd) SetTimeOut(timeout time.Duration, methods ...*MethodConfig) *GrpcClientServiceEtcd {
+	svc.timeout = timeout
+	svc.methods = methods
+
+	return svc
+}
+
 func (svc *GrpcClientServiceEtcd) DialEtcd() *GrpcClientServiceEtcd {
 	grpc_prometheus.EnableClientHandlingTimeHistogram()
+
+	serviceCfg, cfgErr := getServiceConfig(svc.methods)
+	if cfgErr != nil {
+		logs.Logger.Fatal("[GrpcClient ServiceConfig]", zap.Error(cfgErr))
+	}
+
+	timeout := svc.timeout * time.Millisecond
+	if timeout <= 0 {
+		timeout = TimeOutDefault
+	}
+
+	var resolverOpts []grpc.DialOption
+	if svc.registry != nil {
+		resolverOpts = append(resolverOpts, grpc.WithResolvers(NewRegistryBuilder(svc.registry, svc.namespace)))
+	}
+
 	for k, v := range svc.router {
-		svc.conn[k], err = grpc.Dial(v, grpc.WithInsecure(),
+		svc.conn[k], err = grpc.Dial(v, append(resolverOpts, grpc.WithInsecure(),
 			grpc.WithUnaryInterceptor(grpc_middleware.ChainUnaryClient(
 				grpc_opentracing.UnaryClientInterceptor(),
 				grpc_prometheus.UnaryClientInterceptor,
+				UnaryClientBreaker(),
+				TimeoutUnaryClientInterceptor(methodTimeouts(svc.methods), timeout),
 			)),
 			grpc.WithStreamInterceptor(grpc_middleware.ChainStreamClient(
 				grpc_opentracing.StreamClientInterceptor(),
 				grpc_prometheus.StreamClientInterceptor,
+				StreamClientBreaker(),
 			)),
 			grpc.WithKeepaliveParams(kacp),
 			grpc.WithBackoffMaxDelay(BackoffMaxDelay),
@@ -57,12 +103,13 @@ func (svc *GrpcClientServiceEtcd) DialEtcd() *GrpcClientServiceEtcd {
 			grpc.WithInitialConnWindowSize(grpcInitialConnWindowSize),
 			grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(grpcMaxCallMsgSize)),
 			grpc.WithDefaultCallOptions(grpc.MaxCallSendMsgSize(grpcMaxSendMsgSize)),
-			grpc.WithDefaultServiceConfig(getGrpcRoundrobin()),
-		)
+			grpc.WithDefaultServiceConfig(serviceCfg),
+		)...)
 		if err != nil {
 			logs.Logger.Error("[GrpcClient Dial]", zap.Error(err))
 		}
 	}
 
+	health.Register("grpc_client", health.CheckerFunc(CheckConnState))
 	return svc
 }
@@ -12,9 +12,11 @@ func ClientOpts() []grpc.DialOption {
 	return []grpc.DialOption{
 		grpc.WithChainUnaryInterceptor(
 			grpc_prometheus.UnaryClientInterceptor,
+			UnaryClientBreaker(),
 		),
 		grpc.WithChainStreamInterceptor(
 			grpc_prometheus.StreamClientInterceptor,
+			StreamClientBreaker(),
 		),
 		grpc.WithKeepaliveParams(kacp),
 		grpc.WithInitialWindowSize(grpcInitialWindowSize),
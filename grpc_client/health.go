@@ -0,0 +1,45 @@
+package grpc_client
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+)
+
+// CheckConnState reports every dialed ClientConn that is in
+// TRANSIENT_FAILURE or SHUTDOWN, without changing any connection state.
+func CheckConnState(ctx context.Context) error {
+	conns := make(map[string]*grpc.ClientConn)
+	if clientSvr != nil {
+		for name, c := range clientSvr.clientConn {
+			conns[name] = c.Client
+		}
+	}
+
+	if conn != nil {
+		for name, c := range conn.conn {
+			if c != nil {
+				conns[name] = c
+			}
+		}
+	}
+
+	var failed []string
+	for name, c := range conns {
+		state := c.GetState()
+		if state == connectivity.TransientFailure || state == connectivity.Shutdown {
+			failed = append(failed, fmt.Sprintf("%s: %s", name, state))
+		}
+	}
+
+	if len(failed) == 0 {
+		return nil
+	}
+
+	sort.Strings(failed)
+	return fmt.Errorf("grpc client conn not ready: %s", strings.Join(failed, ", "))
+}
@@ -0,0 +1,172 @@
+package grpc_client
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shopastro/go-common/registry"
+	"github.com/shopastro/logs"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/resolver"
+)
+
+type (
+	registryBuilder struct {
+		registry  registry.Registry
+		namespace string
+	}
+
+	registryResolver struct {
+		ctx       context.Context
+		cancel    context.CancelFunc
+		registry  registry.Registry
+		namespace string
+		name      string
+		mu        sync.Mutex
+		watcher   registry.Watcher
+		cc        resolver.ClientConn
+		target    string
+	}
+)
+
+// RegistryScheme resolves targets such as registry:///im-session or
+// registry://<namespace>/im-session through a registry.Registry. A target
+// without a namespace uses the one given to NewRegistryBuilder, which must
+// match the Namespace servers register with.
+const RegistryScheme = "registry"
+
+const (
+	watchBackoffMin = time.Second
+	watchBackoffMax = 30 * time.Second
+)
+
+var errNoInstance = errors.New("registry resolver: no available instance")
+
+func NewRegistryBuilder(r registry.Registry, namespace string) resolver.Builder {
+	return &registryBuilder{registry: r, namespace: namespace}
+}
+
+func (b *registryBuilder) Build(target resolver.Target, cc resolver.ClientConn, opts resolver.BuildOptions) (resolver.Resolver, error) {
+	name := strings.TrimPrefix(target.URL.Path, "/")
+	if name == "" {
+		name = target.URL.Opaque
+	}
+
+	namespace := target.URL.Host
+	if namespace == "" {
+		namespace = b.namespace
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	watcher, err := b.registry.Watch(ctx, namespace, name)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	r := &registryResolver{
+		ctx:       ctx,
+		cancel:    cancel,
+		registry:  b.registry,
+		namespace: namespace,
+		name:      name,
+		watcher:   watcher,
+		cc:        cc,
+		target:    target.URL.String(),
+	}
+	go r.watch()
+
+	return r, nil
+}
+
+func (b *registryBuilder) Scheme() string {
+	return RegistryScheme
+}
+
+// watch keeps the conn updated; on a watcher error it backs off and watches
+// again until the resolver is closed.
+func (r *registryResolver) watch() {
+	backoff := watchBackoffMin
+	for {
+		r.mu.Lock()
+		watcher := r.watcher
+		r.mu.Unlock()
+
+		instances, err := watcher.Next()
+		if err == nil {
+			backoff = watchBackoffMin
+			r.update(instances)
+			continue
+		}
+
+		if r.ctx.Err() != nil {
+			return
+		}
+
+		logs.Logger.Error("[Registry Resolver] watch", zap.String("target", r.target), zap.Error(err))
+		r.cc.ReportError(err)
+
+		for {
+			watcher.Stop()
+
+			select {
+			case <-r.ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+
+			if backoff *= 2; backoff > watchBackoffMax {
+				backoff = watchBackoffMax
+			}
+
+			if watcher, err = r.registry.Watch(r.ctx, r.namespace, r.name); err == nil {
+				break
+			}
+
+			logs.Logger.Error("[Registry Resolver] rewatch", zap.String("target", r.target), zap.Error(err))
+		}
+
+		r.mu.Lock()
+		r.watcher = watcher
+		r.mu.Unlock()
+	}
+}
+
+func (r *registryResolver) update(instances []*registry.ServiceInstance) {
+	addrs := make([]resolver.Address, 0, len(instances))
+	for _, ins := range instances {
+		endpoint, ok := ins.Endpoint("grpc")
+		if !ok {
+			continue
+		}
+
+		addrs = append(addrs, resolver.Address{Addr: endpoint})
+	}
+
+	if len(addrs) == 0 {
+		logs.Logger.Warn("[Registry Resolver] no instance", zap.String("target", r.target))
+		r.cc.ReportError(errNoInstance)
+		return
+	}
+
+	if err := r.cc.UpdateState(resolver.State{Addresses: addrs}); err != nil {
+		logs.Logger.Error("[Registry Resolver] update state", zap.String("target", r.target), zap.Error(err))
+	}
+}
+
+func (r *registryResolver) ResolveNow(resolver.ResolveNowOptions) {}
+
+func (r *registryResolver) Close() {
+	r.cancel()
+
+	r.mu.Lock()
+	watcher := r.watcher
+	r.mu.Unlock()
+
+	if err := watcher.Stop(); err != nil {
+		logs.Logger.Error("[Registry Resolver] stop watcher", zap.String("target", r.target), zap.Error(err))
+	}
+}
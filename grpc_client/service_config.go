@@ -0,0 +1,205 @@
+package grpc_client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/balancer/roundrobin"
+)
+
+type (
+	// MethodConfig applies to Service/Method, or to every method of Service
+	// when Method is empty. Durations are in milliseconds.
+	MethodConfig struct {
+		Service string
+		Method  string
+		Timeout time.Duration
+		Retry   *RetryPolicy
+		Hedging *HedgingPolicy
+	}
+
+	// RetryPolicy codes are gRPC status names, e.g. "UNAVAILABLE".
+	RetryPolicy struct {
+		MaxAttempts       int
+		InitialBackoff    time.Duration
+		MaxBackoff        time.Duration
+		BackoffMultiplier float64
+		RetryableCodes    []string
+	}
+
+	// HedgingPolicy is rendered into the service config but only honoured by
+	// gRPC runtimes that implement hedging.
+	HedgingPolicy struct {
+		MaxAttempts   int
+		HedgingDelay  time.Duration
+		NonFatalCodes []string
+	}
+
+	serviceConfig struct {
+		LoadBalancingPolicy string         `json:"loadBalancingPolicy"`
+		MethodConfig        []methodConfig `json:"methodConfig,omitempty"`
+	}
+
+	methodConfig struct {
+		Name          []methodName   `json:"name"`
+		Timeout       string         `json:"timeout,omitempty"`
+		RetryPolicy   *retryPolicy   `json:"retryPolicy,omitempty"`
+		HedgingPolicy *hedgingPolicy `json:"hedgingPolicy,omitempty"`
+	}
+
+	methodName struct {
+		Service string `json:"service"`
+		Method  string `json:"method,omitempty"`
+	}
+
+	retryPolicy struct {
+		MaxAttempts          int      `json:"maxAttempts"`
+		InitialBackoff       string   `json:"initialBackoff"`
+		MaxBackoff           string   `json:"maxBackoff"`
+		BackoffMultiplier    float64  `json:"backoffMultiplier"`
+		RetryableStatusCodes []string `json:"retryableStatusCodes"`
+	}
+
+	hedgingPolicy struct {
+		MaxAttempts         int      `json:"maxAttempts"`
+		HedgingDelay        string   `json:"hedgingDelay,omitempty"`
+		NonFatalStatusCodes []string `json:"nonFatalStatusCodes,omitempty"`
+	}
+)
+
+const (
+	retryInitialBackoffDefault    = 100 * time.Millisecond
+	retryMaxBackoffDefault        = time.Second
+	retryBackoffMultiplierDefault = 2
+)
+
+// getServiceConfig renders the round robin policy together with methods.
+func getServiceConfig(methods []*MethodConfig) (string, error) {
+	sc := serviceConfig{LoadBalancingPolicy: roundrobin.Name}
+
+	for _, m := range methods {
+		if m.Service == "" {
+			return "", errors.New("method config: service is required")
+		}
+
+		if m.Retry != nil && m.Hedging != nil {
+			return "", fmt.Errorf("method config %s: retry and hedging are mutually exclusive", m.name())
+		}
+
+		mc := methodConfig{Name: []methodName{{Service: m.Service, Method: m.Method}}}
+		if m.Timeout > 0 {
+			mc.Timeout = durationString(m.Timeout * time.Millisecond)
+		}
+
+		if r := m.Retry; r != nil {
+			if r.MaxAttempts < 2 || len(r.RetryableCodes) == 0 {
+				return "", fmt.Errorf("method config %s: retry needs maxAttempts >= 2 and retryable codes", m.name())
+			}
+
+			mc.RetryPolicy = &retryPolicy{
+				MaxAttempts:          r.MaxAttempts,
+				InitialBackoff:       durationString(orDefault(r.InitialBackoff*time.Millisecond, retryInitialBackoffDefault)),
+				MaxBackoff:           durationString(orDefault(r.MaxBackoff*time.Millisecond, retryMaxBackoffDefault)),
+				BackoffMultiplier:    r.BackoffMultiplier,
+				RetryableStatusCodes: upper(r.RetryableCodes),
+			}
+
+			if mc.RetryPolicy.BackoffMultiplier <= 0 {
+				mc.RetryPolicy.BackoffMultiplier = retryBackoffMultiplierDefault
+			}
+		}
+
+		if h := m.Hedging; h != nil {
+			if h.MaxAttempts < 2 {
+				return "", fmt.Errorf("method config %s: hedging needs maxAttempts >= 2", m.name())
+			}
+
+			mc.HedgingPolicy = &hedgingPolicy{
+				MaxAttempts:         h.MaxAttempts,
+				NonFatalStatusCodes: upper(h.NonFatalCodes),
+			}
+
+			if h.HedgingDelay > 0 {
+				mc.HedgingPolicy.HedgingDelay = durationString(h.HedgingDelay * time.Millisecond)
+			}
+		}
+
+		sc.MethodConfig = append(sc.MethodConfig, mc)
+	}
+
+	body, err := json.Marshal(sc)
+	if err != nil {
+		return "", err
+	}
+
+	return string(body), nil
+}
+
+func (m *MethodConfig) name() string {
+	return "/" + m.Service + "/" + m.Method
+}
+
+// methodTimeouts indexes the configured timeouts by full method name, with
+// "/Service/" holding the service wide value.
+func methodTimeouts(methods []*MethodConfig) map[string]time.Duration {
+	timeouts := make(map[string]time.Duration)
+	for _, m := range methods {
+		if m.Timeout > 0 {
+			timeouts[m.name()] = m.Timeout * time.Millisecond
+		}
+	}
+
+	return timeouts
+}
+
+// TimeoutUnaryClientInterceptor sets the configured deadline on calls whose
+// context has none, falling back to the service wide timeout and then to def.
+func TimeoutUnaryClientInterceptor(timeouts map[string]time.Duration, def time.Duration) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if _, ok := ctx.Deadline(); !ok {
+			timeout, ok := timeouts[method]
+			if !ok {
+				timeout, ok = timeouts[method[:strings.LastIndex(method, "/")+1]]
+			}
+
+			if !ok {
+				timeout = def
+			}
+
+			if timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, timeout)
+				defer cancel()
+			}
+		}
+
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+func durationString(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64) + "s"
+}
+
+func orDefault(d, def time.Duration) time.Duration {
+	if d <= 0 {
+		return def
+	}
+
+	return d
+}
+
+func upper(codes []string) []string {
+	res := make([]string, 0, len(codes))
+	for _, c := range codes {
+		res = append(res, strings.ToUpper(c))
+	}
+
+	return res
+}
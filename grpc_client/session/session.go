@@ -36,12 +36,27 @@ func GetSessionInfo(ctx context.Context, scheme, dewuUid string, sid string, uid
 		logs.Logger.Error("getewaySessionClient is nil")
 		return nil, errors.New("getewaySessionClient is nil")
 	}
-	c, cancel := context.WithTimeout(ctx, grpc_client.GetGrpcClient(sessionGrpcClientServiceName).TimeOut)
+	c, cancel := withTimeout(ctx)
 	defer cancel()
 	sess, err := sessionClient.Find(c, in)
 	return sess, err
 }
 
+// withTimeout bounds calls made without a deadline, the conn passed to
+// SetsessionClient may carry no timeout interceptor.
+func withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return context.WithCancel(ctx)
+	}
+
+	timeout := grpc_client.TimeOutDefault
+	if c := grpc_client.GetGrpcClient(sessionGrpcClientServiceName); c != nil && c.TimeOut > 0 {
+		timeout = c.TimeOut
+	}
+
+	return context.WithTimeout(ctx, timeout)
+}
+
 func GetUidByUname(ctx context.Context, uname string) (int64, error) {
 	scheme, dewuUid, err := unameToScheme(uname)
 	if err != nil {
@@ -71,7 +86,9 @@ func GetUidByDewuUid(ctx context.Context, scheme, dewuUid string, from string) (
 		return 0, fmt.Errorf("params error")
 	}
 	mapping := &session.Mapping{Scheme: scheme, Uid: dewuUid, From: from}
-	res, err := sessionClient.GetMapping(ctx, mapping)
+	c, cancel := withTimeout(ctx)
+	defer cancel()
+	res, err := sessionClient.GetMapping(c, mapping)
 	if err != nil {
 		logs.Logger.Error("sessionClient.SetMapping error", zap.Error(err))
 		return 0, err
@@ -92,7 +109,9 @@ func GetDewuUid(ctx context.Context, cid int64) (string, string, error) {
 		return "", "", fmt.Errorf("params error")
 	}
 	mapping := &session.Mapping{Cid: cid}
-	res, err := sessionClient.GetMapping(ctx, mapping)
+	c, cancel := withTimeout(ctx)
+	defer cancel()
+	res, err := sessionClient.GetMapping(c, mapping)
 	if err != nil {
 		logs.Logger.Error("sessionClient.SetMapping error", zap.Error(err))
 		return "", "", err
@@ -134,7 +153,9 @@ func GetDuUidFromMapping(ctx context.Context, mapping *session.Mapping) (string,
 		return "", "", fmt.Errorf("params error")
 	}
 	//mapping := &session.Mapping{Cid: cid}
-	res, err := sessionClient.GetMapping(ctx, mapping)
+	c, cancel := withTimeout(ctx)
+	defer cancel()
+	res, err := sessionClient.GetMapping(c, mapping)
 	if err != nil {
 		logs.Logger.Error("sessionClient.SetMapping error", zap.Error(err))
 		return "", "", err
@@ -153,7 +174,9 @@ func GetUidFromMapping(ctx context.Context, mapping *session.Mapping) (int64, er
 		logs.Logger.Error("GetTinodeUid params error", zap.Any("mapping", mapping))
 		return 0, fmt.Errorf("params error")
 	}
-	res, err := sessionClient.GetMapping(ctx, mapping)
+	c, cancel := withTimeout(ctx)
+	defer cancel()
+	res, err := sessionClient.GetMapping(c, mapping)
 	if err != nil {
 		logs.Logger.Error("sessionClient.SetMapping error", zap.Error(err))
 		return 0, err
@@ -0,0 +1,235 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+type (
+	Checker interface {
+		Check(ctx context.Context) error
+	}
+
+	CheckerFunc func(ctx context.Context) error
+
+	Registry struct {
+		mu        sync.RWMutex
+		timeout   time.Duration
+		liveness  map[string]Checker
+		readiness map[string]Checker
+	}
+
+	Result struct {
+		Name    string  `json:"name"`
+		Status  string  `json:"status"`
+		Error   string  `json:"error,omitempty"`
+		Latency float64 `json:"latency"`
+	}
+
+	Report struct {
+		Status string    `json:"status"`
+		Checks []*Result `json:"checks"`
+	}
+
+	panicError struct {
+		p interface{}
+	}
+)
+
+const (
+	StatusUp   = "UP"
+	StatusDown = "DOWN"
+
+	TimeoutDefault = 3 * time.Second
+)
+
+var (
+	defaultRegistry = NewRegistry()
+)
+
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		timeout:   TimeoutDefault,
+		liveness:  make(map[string]Checker),
+		readiness: make(map[string]Checker),
+	}
+}
+
+func GetRegistry() *Registry {
+	return defaultRegistry
+}
+
+// Register adds a readiness checker to the default registry.
+func Register(name string, c Checker) {
+	defaultRegistry.Register(name, c)
+}
+
+// RegisterLiveness adds a liveness checker to the default registry.
+func RegisterLiveness(name string, c Checker) {
+	defaultRegistry.RegisterLiveness(name, c)
+}
+
+func Unregister(name string) {
+	defaultRegistry.Unregister(name)
+}
+
+func Live(ctx context.Context) *Report {
+	return defaultRegistry.Live(ctx)
+}
+
+func Ready(ctx context.Context) *Report {
+	return defaultRegistry.Ready(ctx)
+}
+
+// SetTimeout sets the timeout applied to every single check.
+func (r *Registry) SetTimeout(timeout time.Duration) *Registry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.timeout = timeout
+
+	return r
+}
+
+func (r *Registry) Register(name string, c Checker) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.readiness[name] = c
+}
+
+func (r *Registry) RegisterLiveness(name string, c Checker) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.liveness[name] = c
+}
+
+func (r *Registry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.readiness, name)
+	delete(r.liveness, name)
+}
+
+func (r *Registry) Live(ctx context.Context) *Report {
+	r.mu.RLock()
+	checkers := copyCheckers(r.liveness)
+	timeout := r.timeout
+	r.mu.RUnlock()
+
+	return run(ctx, checkers, timeout)
+}
+
+// Ready runs the readiness checkers; a service that is not live is not
+// ready either, so liveness checkers are included.
+func (r *Registry) Ready(ctx context.Context) *Report {
+	r.mu.RLock()
+	checkers := copyCheckers(r.liveness)
+	for name, c := range r.readiness {
+		checkers[name] = c
+	}
+	timeout := r.timeout
+	r.mu.RUnlock()
+
+	return run(ctx, checkers, timeout)
+}
+
+func (rp *Report) IsUp() bool {
+	return rp.Status == StatusUp
+}
+
+func run(ctx context.Context, checkers map[string]Checker, timeout time.Duration) *Report {
+	report := &Report{
+		Status: StatusUp,
+		Checks: make([]*Result, 0, len(checkers)),
+	}
+
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+
+	for name, c := range checkers {
+		wg.Add(1)
+		go func(name string, c Checker) {
+			defer wg.Done()
+
+			res := check(ctx, name, c, timeout)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			report.Checks = append(report.Checks, res)
+			if res.Status != StatusUp {
+				report.Status = StatusDown
+			}
+		}(name, c)
+	}
+
+	wg.Wait()
+
+	sort.Slice(report.Checks, func(i, j int) bool {
+		return report.Checks[i].Name < report.Checks[j].Name
+	})
+
+	return report
+}
+
+func check(ctx context.Context, name string, c Checker, timeout time.Duration) *Result {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	errCh := make(chan error, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				errCh <- panicError{p}
+			}
+		}()
+
+		errCh <- c.Check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	res := &Result{
+		Name:    name,
+		Status:  StatusUp,
+		Latency: float64(time.Since(start)) / float64(time.Second),
+	}
+
+	if err != nil {
+		res.Status = StatusDown
+		res.Error = err.Error()
+	}
+
+	return res
+}
+
+func copyCheckers(src map[string]Checker) map[string]Checker {
+	dst := make(map[string]Checker, len(src))
+	for k, v := range src {
+		dst[k] = v
+	}
+
+	return dst
+}
+
+func (e panicError) Error() string {
+	return fmt.Sprintf("health check panic: %v", e.p)
+}
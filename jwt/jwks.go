@@ -0,0 +1,260 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/shopastro/logs"
+	"go.uber.org/zap"
+)
+
+type (
+	jwk struct {
+		Kty string `json:"kty"`
+		Kid string `json:"kid"`
+		Alg string `json:"alg"`
+		Use string `json:"use"`
+		K   string `json:"k"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+		Crv string `json:"crv"`
+		X   string `json:"x"`
+		Y   string `json:"y"`
+	}
+
+	// keySet caches a JWKS, it is reloaded every refresh and, at most every
+	// minRefresh, when a token names an unknown kid. Concurrent reloads are
+	// collapsed into one.
+	keySet struct {
+		mu       sync.RWMutex
+		loadMu   sync.Mutex
+		file     string
+		url      string
+		refresh  time.Duration
+		loadedAt time.Time
+		keys     map[string]interface{}
+		client   *http.Client
+	}
+)
+
+const (
+	defaultJWKSRefresh = 5 * time.Minute
+	minRefresh         = 10 * time.Second
+)
+
+func newKeySet(file, url string, refresh time.Duration) *keySet {
+	if refresh <= 0 {
+		refresh = defaultJWKSRefresh
+	}
+
+	return &keySet{
+		file:    file,
+		url:     url,
+		refresh: refresh,
+		keys:    make(map[string]interface{}),
+		client:  &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+func (ks *keySet) get(kid, alg string) (interface{}, error) {
+	ks.mu.RLock()
+	loadedAt := ks.loadedAt
+	key, ok := ks.find(kid, alg)
+	ks.mu.RUnlock()
+
+	if age := time.Since(loadedAt); age > ks.refresh || (!ok && age > minRefresh) {
+		if err := ks.reload(loadedAt); err != nil {
+			logs.Logger.Error("[JWKS]", zap.String("file", ks.file), zap.String("url", ks.url), zap.Error(err))
+		}
+
+		ks.mu.RLock()
+		key, ok = ks.find(kid, alg)
+		ks.mu.RUnlock()
+	}
+
+	if !ok {
+		return nil, fmt.Errorf("%w: kid %q", ErrUnknownKey, kid)
+	}
+
+	return key, nil
+}
+
+// reload loads the set unless another caller did so after loadedAt while
+// this one was waiting.
+func (ks *keySet) reload(loadedAt time.Time) error {
+	ks.loadMu.Lock()
+	defer ks.loadMu.Unlock()
+
+	ks.mu.RLock()
+	fresh := ks.loadedAt.After(loadedAt)
+	ks.mu.RUnlock()
+	if fresh {
+		return nil
+	}
+
+	return ks.load()
+}
+
+// find picks kid, or the only key usable with alg when the token has no kid.
+func (ks *keySet) find(kid, alg string) (interface{}, bool) {
+	if kid != "" {
+		key, ok := ks.keys[kid]
+		return key, ok
+	}
+
+	var found interface{}
+	for _, key := range ks.keys {
+		if keyAlg(key) != alg {
+			continue
+		}
+
+		if found != nil {
+			return nil, false
+		}
+
+		found = key
+	}
+
+	return found, found != nil
+}
+
+func (ks *keySet) load() error {
+	var (
+		data []byte
+		err  error
+	)
+
+	if ks.url != "" {
+		data, err = ks.fetch()
+	} else {
+		data, err = ioutil.ReadFile(ks.file)
+	}
+
+	ks.mu.Lock()
+	ks.loadedAt = time.Now()
+	ks.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+
+	if err := json.Unmarshal(data, &set); err != nil {
+		return err
+	}
+
+	keys := make(map[string]interface{})
+	for i, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+
+		key, err := k.key()
+		if err != nil {
+			logs.Logger.Error("[JWKS key]", zap.String("kid", k.Kid), zap.Error(err))
+			continue
+		}
+
+		kid := k.Kid
+		if kid == "" {
+			kid = fmt.Sprintf("#%d", i)
+		}
+
+		keys[kid] = key
+	}
+
+	ks.mu.Lock()
+	ks.keys = keys
+	ks.mu.Unlock()
+
+	return nil
+}
+
+func (ks *keySet) fetch() ([]byte, error) {
+	resp, err := ks.client.Get(ks.url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("jwks: %s returned %d", ks.url, resp.StatusCode)
+	}
+
+	return ioutil.ReadAll(resp.Body)
+}
+
+func (k jwk) key() (interface{}, error) {
+	switch k.Kty {
+	case "oct":
+		return base64.RawURLEncoding.DecodeString(k.K)
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("jwks: unsupported curve %s", k.Crv)
+		}
+
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		if !elliptic.P256().IsOnCurve(x, y) {
+			return nil, fmt.Errorf("jwks: point of key %s is not on %s", k.Kid, k.Crv)
+		}
+
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("jwks: unsupported key type %s", k.Kty)
+	}
+}
+
+func keyAlg(key interface{}) string {
+	switch key.(type) {
+	case []byte:
+		return HS256
+	case *rsa.PublicKey:
+		return RS256
+	case *ecdsa.PublicKey:
+		return ES256
+	}
+
+	return ""
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).SetBytes(data), nil
+}
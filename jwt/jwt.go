@@ -0,0 +1,382 @@
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopastro/go-common/controller"
+)
+
+type (
+	// Config needs Secret for HS256 and a JWKS file or url for RS256/ES256,
+	// JWKSRefresh and Leeway are milliseconds. Tokens without exp are
+	// rejected unless AllowMissingExp is set.
+	Config struct {
+		Secret      string   `json:"secret" yaml:"secret"`
+		JWKSFile    string   `json:"jwksFile" yaml:"jwksFile"`
+		JWKSURL     string   `json:"jwksUrl" yaml:"jwksUrl"`
+		JWKSRefresh int      `json:"jwksRefresh" yaml:"jwksRefresh"`
+		Issuer      string   `json:"issuer" yaml:"issuer"`
+		Audience    string   `json:"audience" yaml:"audience"`
+		Algorithms  []string `json:"algorithms" yaml:"algorithms"`
+		Leeway      int      `json:"leeway" yaml:"leeway"`
+
+		AllowMissingExp bool `json:"allowMissingExp" yaml:"allowMissingExp"`
+	}
+
+	Claims struct {
+		Issuer    string   `json:"iss,omitempty"`
+		Subject   string   `json:"sub,omitempty"`
+		Audience  Audience `json:"aud,omitempty"`
+		ExpiresAt int64    `json:"exp,omitempty"`
+		NotBefore int64    `json:"nbf,omitempty"`
+		IssuedAt  int64    `json:"iat,omitempty"`
+		ID        string   `json:"jti,omitempty"`
+		// Scope is space separated, as in OAuth 2.0.
+		Scope   string   `json:"scope,omitempty"`
+		Uuid    string   `json:"uuid,omitempty"`
+		UserId  uint64   `json:"userId,omitempty"`
+		IsGuest bool     `json:"isGuest,omitempty"`
+		Roles   []string `json:"roles,omitempty"`
+	}
+
+	// Audience is a string or an array of strings.
+	Audience []string
+
+	Validator struct {
+		secret     []byte
+		keys       *keySet
+		issuer     string
+		audience   string
+		algorithms map[string]bool
+		leeway     time.Duration
+		requireExp bool
+	}
+
+	header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid,omitempty"`
+		Typ string `json:"typ,omitempty"`
+	}
+
+	claimsKey struct{}
+)
+
+const (
+	HS256 = "HS256"
+	RS256 = "RS256"
+	ES256 = "ES256"
+)
+
+var (
+	ErrTokenMissing     = errors.New("jwt: token missing")
+	ErrTokenMalformed   = errors.New("jwt: token malformed")
+	ErrAlgorithm        = errors.New("jwt: algorithm not allowed")
+	ErrUnknownKey       = errors.New("jwt: no key for token")
+	ErrSignatureInvalid = errors.New("jwt: signature invalid")
+	ErrTokenExpired     = errors.New("jwt: token expired")
+	ErrExpMissing       = errors.New("jwt: exp claim missing")
+	ErrTokenNotValidYet = errors.New("jwt: token not valid yet")
+	ErrIssuer           = errors.New("jwt: issuer mismatch")
+	ErrAudience         = errors.New("jwt: audience mismatch")
+)
+
+func NewValidator(cfg *Config) (*Validator, error) {
+	v := &Validator{
+		secret:     []byte(cfg.Secret),
+		issuer:     cfg.Issuer,
+		audience:   cfg.Audience,
+		algorithms: make(map[string]bool),
+		leeway:     time.Duration(cfg.Leeway) * time.Millisecond,
+		requireExp: !cfg.AllowMissingExp,
+	}
+
+	// HS256 is only allowed by default along with a Secret
+	algorithms := cfg.Algorithms
+	if len(algorithms) == 0 {
+		algorithms = []string{RS256, ES256}
+		if cfg.Secret != "" {
+			algorithms = append(algorithms, HS256)
+		}
+	}
+
+	for _, alg := range algorithms {
+		v.algorithms[strings.ToUpper(alg)] = true
+	}
+
+	if cfg.JWKSFile != "" || cfg.JWKSURL != "" {
+		v.keys = newKeySet(cfg.JWKSFile, cfg.JWKSURL, time.Duration(cfg.JWKSRefresh)*time.Millisecond)
+		if err := v.keys.load(); err != nil {
+			return nil, err
+		}
+	}
+
+	return v, nil
+}
+
+// Parse verifies the signature and the time, issuer and audience claims.
+func (v *Validator) Parse(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrTokenMalformed
+	}
+
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return nil, ErrTokenMalformed
+	}
+
+	if !v.algorithms[h.Alg] {
+		return nil, fmt.Errorf("%w: %s", ErrAlgorithm, h.Alg)
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrTokenMalformed
+	}
+
+	key, err := v.key(h)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := verify(h.Alg, key, parts[0]+"."+parts[1], sig); err != nil {
+		return nil, err
+	}
+
+	claims := &Claims{}
+	if err := decodeSegment(parts[1], claims); err != nil {
+		return nil, ErrTokenMalformed
+	}
+
+	return claims, v.validate(claims)
+}
+
+func (v *Validator) key(h header) (interface{}, error) {
+	if h.Alg == HS256 && len(v.secret) > 0 && (h.Kid == "" || v.keys == nil) {
+		return v.secret, nil
+	}
+
+	if v.keys == nil {
+		return nil, ErrUnknownKey
+	}
+
+	return v.keys.get(h.Kid, h.Alg)
+}
+
+func (v *Validator) validate(c *Claims) error {
+	now := time.Now()
+	if c.ExpiresAt == 0 && v.requireExp {
+		return ErrExpMissing
+	}
+
+	if c.ExpiresAt != 0 && now.After(time.Unix(c.ExpiresAt, 0).Add(v.leeway)) {
+		return ErrTokenExpired
+	}
+
+	if c.NotBefore != 0 && now.Before(time.Unix(c.NotBefore, 0).Add(-v.leeway)) {
+		return ErrTokenNotValidYet
+	}
+
+	if v.issuer != "" && c.Issuer != v.issuer {
+		return ErrIssuer
+	}
+
+	if v.audience != "" && !c.Audience.Contains(v.audience) {
+		return ErrAudience
+	}
+
+	return nil
+}
+
+// Sign issues a token, key is a []byte secret, *rsa.PrivateKey or
+// *ecdsa.PrivateKey matching alg.
+func Sign(claims *Claims, alg, kid string, key interface{}) (string, error) {
+	h, err := json.Marshal(header{Alg: alg, Kid: kid, Typ: "JWT"})
+	if err != nil {
+		return "", err
+	}
+
+	c, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signing := base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(c)
+	digest := sha256.Sum256([]byte(signing))
+
+	var sig []byte
+	switch k := key.(type) {
+	case []byte:
+		if alg != HS256 {
+			return "", ErrAlgorithm
+		}
+
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(signing))
+		sig = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		if alg != RS256 {
+			return "", ErrAlgorithm
+		}
+
+		if sig, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:]); err != nil {
+			return "", err
+		}
+	case *ecdsa.PrivateKey:
+		if alg != ES256 {
+			return "", ErrAlgorithm
+		}
+
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		if err != nil {
+			return "", err
+		}
+
+		sig = make([]byte, 64)
+		r.FillBytes(sig[:32])
+		s.FillBytes(sig[32:])
+	default:
+		return "", ErrUnknownKey
+	}
+
+	return signing + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// verify only accepts a key of the type alg asks for, so a public key can
+// never be used as an HMAC secret.
+func verify(alg string, key interface{}, signing string, sig []byte) error {
+	digest := sha256.Sum256([]byte(signing))
+
+	switch alg {
+	case HS256:
+		secret, ok := key.([]byte)
+		if !ok {
+			return ErrUnknownKey
+		}
+
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(signing))
+		if !hmac.Equal(mac.Sum(nil), sig) {
+			return ErrSignatureInvalid
+		}
+	case RS256:
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return ErrUnknownKey
+		}
+
+		if rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], sig) != nil {
+			return ErrSignatureInvalid
+		}
+	case ES256:
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return ErrUnknownKey
+		}
+
+		if len(sig) != 64 || !ecdsa.Verify(pub, digest[:], new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])) {
+			return ErrSignatureInvalid
+		}
+	default:
+		return ErrAlgorithm
+	}
+
+	return nil
+}
+
+func decodeSegment(seg string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+// Scopes splits Scope.
+func (c *Claims) Scopes() []string {
+	return strings.Fields(c.Scope)
+}
+
+func (c *Claims) HasScopes(scopes ...string) bool {
+	have := make(map[string]bool)
+	for _, s := range c.Scopes() {
+		have[s] = true
+	}
+
+	for _, s := range scopes {
+		if !have[s] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// User is UserId, or Subject when it is numeric.
+func (c *Claims) User() uint64 {
+	if c.UserId != 0 {
+		return c.UserId
+	}
+
+	id, _ := strconv.ParseUint(c.Subject, 10, 64)
+	return id
+}
+
+func (a Audience) Contains(aud string) bool {
+	for _, s := range a {
+		if s == aud {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = Audience{single}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+
+	*a = list
+	return nil
+}
+
+func NewContext(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, claimsKey{}, claims)
+}
+
+func FromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(*Claims)
+	return claims, ok
+}
+
+// TrimBearer strips the controller.BearerTokenPrefix, if any.
+func TrimBearer(token string) string {
+	if n := len(controller.BearerTokenPrefix); len(token) > n && strings.EqualFold(token[:n], controller.BearerTokenPrefix) {
+		return strings.TrimSpace(token[n:])
+	}
+
+	return strings.TrimSpace(token)
+}
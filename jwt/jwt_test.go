@@ -0,0 +1,226 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func encodeSegment(t *testing.T, v interface{}) string {
+	t.Helper()
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func TestParse(t *testing.T) {
+	secret := []byte("secret")
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	valid := func() *Claims {
+		return &Claims{Issuer: "iss", Audience: Audience{"api"}, ExpiresAt: now.Add(time.Hour).Unix()}
+	}
+
+	sign := func(c *Claims, alg string, key interface{}) string {
+		token, err := Sign(c, alg, "", key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return token
+	}
+
+	tests := []struct {
+		name  string
+		cfg   Config
+		token func() string
+		want  error
+	}{
+		{"valid", Config{Secret: "secret", Issuer: "iss", Audience: "api"}, func() string {
+			return sign(valid(), HS256, secret)
+		}, nil},
+		{"wrong secret", Config{Secret: "other"}, func() string {
+			return sign(valid(), HS256, secret)
+		}, ErrSignatureInvalid},
+		{"HS256 off without secret", Config{}, func() string {
+			return sign(valid(), HS256, secret)
+		}, ErrAlgorithm},
+		{"algorithm not configured", Config{Secret: "secret", Algorithms: []string{RS256}}, func() string {
+			return sign(valid(), HS256, secret)
+		}, ErrAlgorithm},
+		{"alg none", Config{Secret: "secret"}, func() string {
+			return encodeSegment(t, header{Alg: "none"}) + "." + encodeSegment(t, valid()) + "."
+		}, ErrAlgorithm},
+		{"RS256 without keys", Config{Secret: "secret"}, func() string {
+			return sign(valid(), RS256, rsaKey)
+		}, ErrUnknownKey},
+		{"malformed", Config{Secret: "secret"}, func() string {
+			return "a.b"
+		}, ErrTokenMalformed},
+		{"expired", Config{Secret: "secret"}, func() string {
+			c := valid()
+			c.ExpiresAt = now.Add(-time.Minute).Unix()
+			return sign(c, HS256, secret)
+		}, ErrTokenExpired},
+		{"expired within leeway", Config{Secret: "secret", Leeway: 120000}, func() string {
+			c := valid()
+			c.ExpiresAt = now.Add(-time.Minute).Unix()
+			return sign(c, HS256, secret)
+		}, nil},
+		{"missing exp", Config{Secret: "secret"}, func() string {
+			c := valid()
+			c.ExpiresAt = 0
+			return sign(c, HS256, secret)
+		}, ErrExpMissing},
+		{"missing exp allowed", Config{Secret: "secret", AllowMissingExp: true}, func() string {
+			c := valid()
+			c.ExpiresAt = 0
+			return sign(c, HS256, secret)
+		}, nil},
+		{"not valid yet", Config{Secret: "secret"}, func() string {
+			c := valid()
+			c.NotBefore = now.Add(time.Hour).Unix()
+			return sign(c, HS256, secret)
+		}, ErrTokenNotValidYet},
+		{"issuer mismatch", Config{Secret: "secret", Issuer: "other"}, func() string {
+			return sign(valid(), HS256, secret)
+		}, ErrIssuer},
+		{"audience mismatch", Config{Secret: "secret", Audience: "admin"}, func() string {
+			return sign(valid(), HS256, secret)
+		}, ErrAudience},
+		{"audience in list", Config{Secret: "secret", Audience: "admin"}, func() string {
+			c := valid()
+			c.Audience = Audience{"api", "admin"}
+			return sign(c, HS256, secret)
+		}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := NewValidator(&tt.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := v.Parse(tt.token()); !errors.Is(err, tt.want) {
+				t.Fatalf("Parse() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseJWKS(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	otherKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	jwks := map[string][]jwk{"keys": {
+		{Kty: "RSA", Kid: "rsa", N: base64.RawURLEncoding.EncodeToString(rsaKey.N.Bytes()), E: "AQAB"},
+		{Kty: "EC", Kid: "ec", Crv: "P-256", X: base64.RawURLEncoding.EncodeToString(ecKey.X.Bytes()), Y: base64.RawURLEncoding.EncodeToString(ecKey.Y.Bytes())},
+	}}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(jwks)
+	}))
+	defer srv.Close()
+
+	v, err := NewValidator(&Config{JWKSURL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims := &Claims{Subject: "42", ExpiresAt: time.Now().Add(time.Hour).Unix()}
+
+	tests := []struct {
+		name string
+		alg  string
+		kid  string
+		key  interface{}
+		want error
+	}{
+		{"RS256", RS256, "rsa", rsaKey, nil},
+		{"ES256", ES256, "ec", ecKey, nil},
+		{"ES256 wrong key", ES256, "ec", otherKey, ErrSignatureInvalid},
+		{"key of other type", ES256, "rsa", ecKey, ErrUnknownKey},
+		{"unknown kid", RS256, "gone", rsaKey, ErrUnknownKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := Sign(claims, tt.alg, tt.kid, tt.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			c, err := v.Parse(token)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Parse() = %v, want %v", err, tt.want)
+			}
+
+			if err == nil && c.User() != 42 {
+				t.Fatalf("User() = %d, want 42", c.User())
+			}
+		})
+	}
+}
+
+func TestJWKOffCurve(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	y := new(big.Int).Add(key.Y, big.NewInt(1))
+
+	k := jwk{Kty: "EC", Crv: "P-256",
+		X: base64.RawURLEncoding.EncodeToString(key.X.Bytes()),
+		Y: base64.RawURLEncoding.EncodeToString(y.Bytes()),
+	}
+
+	if _, err := k.key(); err == nil || !strings.Contains(err.Error(), "not on") {
+		t.Fatalf("key() = %v, want off-curve error", err)
+	}
+}
+
+func TestKeySetReloadOnce(t *testing.T) {
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		time.Sleep(20 * time.Millisecond)
+		w.Write([]byte(`{"keys":[]}`))
+	}))
+	defer srv.Close()
+
+	ks := newKeySet("", srv.URL, time.Hour)
+	ks.loadedAt = time.Now().Add(-time.Minute)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ks.get("unknown", RS256)
+		}()
+	}
+	wg.Wait()
+
+	if n := atomic.LoadInt32(&hits); n != 1 {
+		t.Fatalf("JWKS fetched %d times, want 1", n)
+	}
+}
@@ -9,6 +9,8 @@ import (
 	"github.com/shopastro/logs"
 
 	"github.com/shopastro/go-common/common"
+	"github.com/shopastro/go-common/health"
+	"github.com/shopastro/go-common/shutdown"
 	"go.uber.org/zap"
 	"golang.org/x/net/context"
 	"gorm.io/driver/mysql"
@@ -160,9 +162,51 @@ func (m *ConfigModel) Connection(key ...string) *gorm.DB {
 	}
 
 	connMap.LoadOrStore(getConnKey(key), db)
+	health.Register("mysql", health.CheckerFunc(Ping))
+	shutdown.Register("mysql", func(context.Context) error { return Close() })
 	return db
 }
 
+// Ping pings every connection in connMap.
+func Ping(ctx context.Context) error {
+	var pingErr error
+	connMap.Range(func(key, value interface{}) bool {
+		db, err := value.(*gorm.DB).DB()
+		if err == nil {
+			err = db.PingContext(ctx)
+		}
+
+		if err != nil {
+			pingErr = fmt.Errorf("%v: %w", key, err)
+			return false
+		}
+
+		return true
+	})
+
+	return pingErr
+}
+
+func Close() error {
+	var closeErr error
+	connMap.Range(func(key, value interface{}) bool {
+		db, err := value.(*gorm.DB).DB()
+		if err == nil {
+			err = db.Close()
+		}
+
+		if err != nil {
+			logs.Logger.Error("[mysql Close]", zap.Any("key", key), zap.Error(err))
+			closeErr = err
+		}
+
+		connMap.Delete(key)
+		return true
+	})
+
+	return closeErr
+}
+
 func (m *Model) BeforeSave(tx *gorm.DB) (err error) {
 	m.UpdatedAt = common.NewTools().GetNowMillisecond()
 
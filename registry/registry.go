@@ -0,0 +1,44 @@
+package registry
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+type (
+	ServiceInstance struct {
+		Id        string            `json:"id"`
+		Name      string            `json:"name"`
+		Namespace string            `json:"namespace"`
+		Metadata  map[string]string `json:"metadata"`
+		Endpoints []string          `json:"endpoints"`
+	}
+
+	// Registry registers service instances with a lease of ttl; registering
+	// the same instance again refreshes the lease.
+	Registry interface {
+		Register(ctx context.Context, ins *ServiceInstance, ttl time.Duration) error
+		Deregister(ctx context.Context, ins *ServiceInstance) error
+		Watch(ctx context.Context, namespace, name string) (Watcher, error)
+	}
+
+	// Watcher returns the full instance list on the first call to Next and
+	// then blocks until the list changes.
+	Watcher interface {
+		Next() ([]*ServiceInstance, error)
+		Stop() error
+	}
+)
+
+// Endpoint returns the first endpoint with the given scheme, e.g. "grpc".
+func (ins *ServiceInstance) Endpoint(scheme string) (string, bool) {
+	prefix := scheme + "://"
+	for _, e := range ins.Endpoints {
+		if strings.HasPrefix(e, prefix) {
+			return strings.TrimPrefix(e, prefix), true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,215 @@
+package server
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopastro/go-common/authz"
+	"github.com/shopastro/go-common/controller"
+	"github.com/shopastro/go-common/errcode"
+	"github.com/shopastro/go-common/jwt"
+	"github.com/shopastro/logs"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type (
+	// RoleFunc resolves the roles of a user, ClaimsRoles is used when nil.
+	RoleFunc func(ctx context.Context, user controller.UserInfoHeader) ([]string, error)
+
+	AuthzOption func(*authzOptions)
+
+	authzOptions struct {
+		trustHeaders bool
+	}
+)
+
+// TrustGatewayHeaders takes the user from the POIZON-* headers, as
+// controller.GetTokenInfo does, when JWTHandler did not verify one. Only use
+// it behind a gateway that strips these headers from client requests.
+func TrustGatewayHeaders() AuthzOption {
+	return func(o *authzOptions) {
+		o.trustHeaders = true
+	}
+}
+
+func newAuthzOptions(opts []AuthzOption) *authzOptions {
+	o := &authzOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+// ClaimsRoles takes the roles from the JWT claims, see JWTHandler.
+func ClaimsRoles(ctx context.Context, _ controller.UserInfoHeader) ([]string, error) {
+	if claims, ok := jwt.FromContext(ctx); ok {
+		return claims.Roles, nil
+	}
+
+	return nil, nil
+}
+
+// AuthzHandler authorizes every request with the policy matching its route.
+func AuthzHandler(a *authz.Authorizer, roles RoleFunc, opts ...AuthzOption) gin.HandlerFunc {
+	return authzHandler(a, nil, roles, newAuthzOptions(opts))
+}
+
+// AuthzPolicy authorizes the requests of one route with p, for use in
+// Route.Middlewares.
+func AuthzPolicy(a *authz.Authorizer, p *authz.Policy, roles RoleFunc, opts ...AuthzOption) gin.HandlerFunc {
+	return authzHandler(a, p, roles, newAuthzOptions(opts))
+}
+
+// authzHandler only trusts the user verified by JWTHandler, anyone else is a
+// guest unless TrustGatewayHeaders is set.
+func authzHandler(a *authz.Authorizer, p *authz.Policy, roles RoleFunc, o *authzOptions) gin.HandlerFunc {
+	ctl := &controller.Controller{}
+
+	return func(ctx *gin.Context) {
+		user, ok := UserInfoFromContext(ctx.Request.Context())
+		switch {
+		case ok:
+		case o.trustHeaders:
+			user = ctl.GetTokenInfo(ctx)
+		default:
+			user = controller.UserInfoHeader{IsGuest: true}
+		}
+
+		resource := &authz.Resource{
+			Method: ctx.Request.Method,
+			Path:   ctx.FullPath(),
+			Params: make(map[string]string),
+		}
+
+		for _, param := range ctx.Params {
+			resource.Params[param.Key] = param.Value
+		}
+
+		policy := p
+		if policy == nil {
+			policy = a.Match(resource)
+		}
+
+		subject, err := newSubject(ctx.Request.Context(), user, roles)
+		if err != nil {
+			logs.Logger.Error("[AuthzHandler roles]", zap.String("uri", ctx.Request.RequestURI), zap.Error(err))
+			ctl.ServiceException(ctx, err)
+			ctx.Abort()
+			return
+		}
+
+		d := a.Check(ctx.Request.Context(), policy, subject, resource)
+		if err := decisionError(d); err != nil {
+			ctl.Error(ctx, err)
+			ctx.Abort()
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// decisionError maps a denied decision to errcode.Unauthorized or
+// errcode.Forbidden.
+func decisionError(d *authz.Decision) error {
+	err := d.Err()
+	switch {
+	case err == nil:
+		return nil
+	case d.Unauthenticated:
+		return errcode.Unauthorized.Wrap(err)
+	default:
+		return errcode.Forbidden.Wrap(err)
+	}
+}
+
+func UnaryServerAuthz(a *authz.Authorizer, roles RoleFunc, opts ...AuthzOption) grpc.UnaryServerInterceptor {
+	o := newAuthzOptions(opts)
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := o.grpcAuthorize(ctx, a, roles, info.FullMethod, req); err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerAuthz authorizes when the stream opens, owner checks get no
+// Message.
+func StreamServerAuthz(a *authz.Authorizer, roles RoleFunc, opts ...AuthzOption) grpc.StreamServerInterceptor {
+	o := newAuthzOptions(opts)
+
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := o.grpcAuthorize(ss.Context(), a, roles, info.FullMethod, nil); err != nil {
+			return err
+		}
+
+		return handler(srv, ss)
+	}
+}
+
+func (o *authzOptions) grpcAuthorize(ctx context.Context, a *authz.Authorizer, roles RoleFunc, fullMethod string, req interface{}) error {
+	user, ok := UserInfoFromContext(ctx)
+	switch {
+	case ok:
+	case o.trustHeaders:
+		user = metadataUser(ctx)
+	default:
+		user = controller.UserInfoHeader{IsGuest: true}
+	}
+
+	subject, err := newSubject(ctx, user, roles)
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	d := a.Authorize(ctx, subject, &authz.Resource{GrpcMethod: fullMethod, Message: req})
+
+	return decisionError(d)
+}
+
+// metadataUser reads the POIZON-* headers from the incoming metadata like
+// controller.GetTokenInfo, a missing or invalid POIZON-ISGUEST is a guest.
+func metadataUser(ctx context.Context) controller.UserInfoHeader {
+	md, _ := metadata.FromIncomingContext(ctx)
+	get := func(key string) string {
+		if values := md.Get(strings.ToLower(key)); len(values) > 0 {
+			return values[0]
+		}
+
+		return ""
+	}
+
+	userId, _ := strconv.ParseUint(get(controller.PoizonUserId), 10, 64)
+	isGuest, err := strconv.ParseBool(get(controller.PoizonIsGuest))
+	if err != nil {
+		isGuest = true
+	}
+
+	return controller.UserInfoHeader{
+		Uuid:    get(controller.PoizonUuid),
+		UserId:  userId,
+		IsGuest: isGuest,
+	}
+}
+
+func newSubject(ctx context.Context, user controller.UserInfoHeader, roles RoleFunc) (*authz.Subject, error) {
+	if roles == nil {
+		roles = ClaimsRoles
+	}
+
+	r, err := roles(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	return &authz.Subject{User: user, Roles: r}, nil
+}
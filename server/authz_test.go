@@ -0,0 +1,112 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopastro/go-common/authz"
+	"github.com/shopastro/go-common/controller"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestAuthzHandlerIdentity(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	a := authz.NewAuthorizer(&authz.PolicySet{}, authz.WithDecisionLogger(nil),
+		authz.WithOwner("self", func(ctx context.Context, s *authz.Subject, r *authz.Resource) (bool, error) {
+			return r.Params["id"] == "7" && s.User.UserId == 7, nil
+		}))
+	policy := &authz.Policy{Owner: "self"}
+
+	tests := []struct {
+		name   string
+		user   *controller.UserInfoHeader
+		header map[string]string
+		opts   []AuthzOption
+		status int
+		code   int
+	}{
+		{"verified owner", &controller.UserInfoHeader{UserId: 7}, nil, nil, http.StatusOK, 0},
+		{"verified other user", &controller.UserInfoHeader{UserId: 8}, nil, nil, http.StatusForbidden, http.StatusForbidden},
+		{"spoofed headers are a guest", nil, map[string]string{
+			controller.PoizonUserId:  "7",
+			controller.PoizonIsGuest: "false",
+		}, nil, http.StatusUnauthorized, http.StatusUnauthorized},
+		{"trusted gateway headers", nil, map[string]string{
+			controller.PoizonUserId:  "7",
+			controller.PoizonIsGuest: "false",
+		}, []AuthzOption{TrustGatewayHeaders()}, http.StatusOK, 0},
+		{"verified user wins over headers", &controller.UserInfoHeader{UserId: 8}, map[string]string{
+			controller.PoizonUserId:  "7",
+			controller.PoizonIsGuest: "false",
+		}, []AuthzOption{TrustGatewayHeaders()}, http.StatusForbidden, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := gin.New()
+			engine.GET("/users/:id", func(ctx *gin.Context) {
+				if tt.user != nil {
+					ctx.Request = ctx.Request.WithContext(context.WithValue(ctx.Request.Context(), userInfoKey{}, *tt.user))
+				}
+			}, AuthzPolicy(a, policy, nil, tt.opts...), func(ctx *gin.Context) {
+				ctx.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/users/7", nil)
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+
+			if tt.code == 0 {
+				return
+			}
+
+			var resp controller.Response
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+
+			if resp.Code != tt.code || resp.Msg == "" || resp.Msg == authz.ErrForbidden.Error() || resp.Msg == authz.ErrUnauthenticated.Error() {
+				t.Fatalf("response = %+v", resp)
+			}
+		})
+	}
+}
+
+func TestGrpcAuthorizeTrustedHeaders(t *testing.T) {
+	a := authz.NewAuthorizer(&authz.PolicySet{Policies: []*authz.Policy{{GrpcMethod: "/test.Service/*"}}}, authz.WithDecisionLogger(nil))
+	md := metadata.Pairs(controller.PoizonUserId, "7", controller.PoizonIsGuest, "false")
+	ctx := metadata.NewIncomingContext(context.Background(), md)
+
+	tests := []struct {
+		name string
+		opts []AuthzOption
+		want codes.Code
+	}{
+		{"headers ignored", nil, codes.Unauthenticated},
+		{"headers trusted", []AuthzOption{TrustGatewayHeaders()}, codes.OK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := newAuthzOptions(tt.opts).grpcAuthorize(ctx, a, nil, "/test.Service/Get", nil)
+			if code := status.Code(err); code != tt.want {
+				t.Fatalf("grpcAuthorize() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
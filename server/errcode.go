@@ -0,0 +1,69 @@
+package server
+
+import (
+	"context"
+
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"github.com/shopastro/go-common/errcode"
+	"github.com/shopastro/logs"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// UnaryServerAppError returns errcode.AppError as a status with the code
+// and the message in the language of the call, see grpcLocalizer.
+func UnaryServerAppError(bundle *i18n.Bundle) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		return resp, appErrorStatus(ctx, bundle, err)
+	}
+}
+
+func StreamServerAppError(bundle *i18n.Bundle) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return appErrorStatus(ss.Context(), bundle, handler(srv, ss))
+	}
+}
+
+func appErrorStatus(ctx context.Context, bundle *i18n.Bundle, err error) error {
+	appErr, ok := errcode.FromError(err)
+	if !ok {
+		return err
+	}
+
+	return appErr.LocalizedStatus(grpcLocalizer(ctx, bundle)).Err()
+}
+
+// grpcLocalizer picks the language from the lang or accept-language
+// metadata, the latter also as forwarded by the gateway.
+func grpcLocalizer(ctx context.Context, bundle *i18n.Bundle) *i18n.Localizer {
+	if bundle == nil {
+		return nil
+	}
+
+	var langs []string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for _, key := range []string{"lang", "accept-language", "grpcgateway-accept-language"} {
+			langs = append(langs, md.Get(key)...)
+		}
+	}
+
+	return i18n.NewLocalizer(bundle, langs...)
+}
+
+// loadI18n fills the I18nBundle with the registered error codes and the
+// translation files in I18nDir.
+func (svc *GinServer) loadI18n() {
+	if err := errcode.AddMessages(svc.I18nBundle); err != nil {
+		logs.Logger.Error("[I18n errcode]", zap.Error(err))
+	}
+
+	if svc.ServerCfg.I18nDir == "" {
+		return
+	}
+
+	if err := errcode.LoadTranslations(svc.I18nBundle, svc.ServerCfg.I18nDir); err != nil {
+		logs.Logger.Error("[I18n]", zap.String("dir", svc.ServerCfg.I18nDir), zap.Error(err))
+	}
+}
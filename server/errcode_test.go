@@ -0,0 +1,59 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"github.com/shopastro/go-common/controller"
+	"golang.org/x/text/language"
+)
+
+func TestLocalizedMessages(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	svc := &GinServer{ServerCfg: &Config{}, I18nBundle: i18n.NewBundle(language.English)}
+	svc.loadI18n()
+
+	engine := gin.New()
+	engine.GET("/", svc.Localizer, func(ctx *gin.Context) {
+		(&controller.Controller{}).ParamsException(ctx, errors.New("bad id"))
+	})
+
+	tests := []struct {
+		name string
+		lang string
+		want string
+	}{
+		{"english", "en", "Invalid parameters"},
+		{"english region", "en-US", "Invalid parameters"},
+		{"chinese", "zh-CN,zh;q=0.9", "参数错误"},
+		{"untranslated", "fr", "Invalid parameters"},
+		{"no preference", "", "Invalid parameters"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.lang != "" {
+				req.Header.Set("Accept-Language", tt.lang)
+			}
+
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+
+			var resp controller.Response
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+
+			if resp.Msg != tt.want {
+				t.Fatalf("Msg = %q, want %q", resp.Msg, tt.want)
+			}
+		})
+	}
+}
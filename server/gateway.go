@@ -0,0 +1,324 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	grpc_opentracing "github.com/grpc-ecosystem/go-grpc-middleware/tracing/opentracing"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"github.com/shopastro/go-common/common"
+	"github.com/shopastro/go-common/controller"
+	"github.com/shopastro/go-common/errcode"
+	"github.com/shopastro/logs"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+)
+
+type (
+	// GatewayRegister matches the generated Register<Service>HandlerFromEndpoint.
+	GatewayRegister func(ctx context.Context, mux *runtime.ServeMux, endpoint string, opts []grpc.DialOption) error
+
+	ginContextKey struct{}
+
+	// gatewayWriter buffers unary responses so they can be wrapped in
+	// controller.Response; once the gateway flushes (server streaming) it
+	// passes everything through untouched.
+	gatewayWriter struct {
+		gin.ResponseWriter
+		buf       bytes.Buffer
+		status    int
+		streaming bool
+	}
+)
+
+const (
+	gatewayHandled = "GatewayHandled"
+)
+
+// NewGatewayMux returns a ServeMux whose errors are rendered as
+// controller.Response with the gRPC code mapped to Code/Status.
+func (svc *GinServer) NewGatewayMux(opts ...runtime.ServeMuxOption) *runtime.ServeMux {
+	return runtime.NewServeMux(append([]runtime.ServeMuxOption{
+		runtime.WithErrorHandler(gatewayErrorHandler),
+	}, opts...)...)
+}
+
+// RegisterGateway mounts mux under RouterGroup at prefix, so it shares the
+// ContextPath, tracing and Localizer middlewares. Paths in the proto http
+// rules are matched relative to ContextPath, e.g. prefix "/v1" for
+// "/v1/users/{id}". The registers dial the local gRPC server.
+func (svc *GinServer) RegisterGateway(prefix string, mux *runtime.ServeMux, registers ...GatewayRegister) error {
+	creds, err := svc.gatewayCredentials()
+	if err != nil {
+		return err
+	}
+
+	opts := []grpc.DialOption{
+		creds,
+		grpc.WithChainUnaryInterceptor(grpc_opentracing.UnaryClientInterceptor()),
+		grpc.WithChainStreamInterceptor(grpc_opentracing.StreamClientInterceptor()),
+	}
+
+	endpoint := svc.grpcEndpoint()
+	for _, register := range registers {
+		if err := register(context.Background(), mux, endpoint, opts); err != nil {
+			return err
+		}
+	}
+
+	handler := svc.gatewayHandler(mux)
+	svc.RouterGroup.Any(strings.TrimRight(prefix, "/")+"/*gateway", handler)
+
+	return nil
+}
+
+func (svc *GinServer) gatewayHandler(mux *runtime.ServeMux) gin.HandlerFunc {
+	contextPath := strings.TrimRight(svc.ServerCfg.ContextPath, "/")
+	ctl := controller.NewController(&controller.Controller{})
+
+	return func(ctx *gin.Context) {
+		req := ctx.Request.Clone(context.WithValue(ctx.Request.Context(), ginContextKey{}, ctx))
+		req.URL.Path = strings.TrimPrefix(req.URL.Path, contextPath)
+		req.URL.RawPath = ""
+
+		w := &gatewayWriter{ResponseWriter: ctx.Writer, status: http.StatusOK}
+		mux.ServeHTTP(w, req)
+
+		if w.streaming || ctx.GetBool(gatewayHandled) {
+			return
+		}
+
+		if w.status != http.StatusOK {
+			ctx.Data(w.status, w.Header().Get("Content-Type"), w.buf.Bytes())
+			return
+		}
+
+		ctl.Response(ctx, json.RawMessage(w.buf.Bytes()))
+	}
+}
+
+func gatewayErrorHandler(ctx context.Context, mux *runtime.ServeMux, m runtime.Marshaler, w http.ResponseWriter, r *http.Request, err error) {
+	ginCtx, ok := r.Context().Value(ginContextKey{}).(*gin.Context)
+	if !ok {
+		runtime.DefaultHTTPErrorHandler(ctx, mux, m, w, r, err)
+		return
+	}
+
+	st := status.Convert(err)
+	code := GrpcCodeToStatus(st.Code())
+
+	httpStatus := http.StatusOK
+	if st.Code() == codes.Unauthenticated {
+		httpStatus = http.StatusUnauthorized
+	}
+
+	// errcode statuses are localized by UnaryServerAppError already
+	msg := st.Message()
+	if c, ok := errcode.FromStatus(st); ok {
+		code, httpStatus = c.Code, c.Status()
+	} else if l, ok := ginCtx.Get(Localizer); ok && l != nil {
+		if m, err := l.(*i18n.Localizer).Localize(&i18n.LocalizeConfig{MessageID: strconv.Itoa(code)}); err == nil {
+			msg = m
+		}
+	}
+
+	logs.Logger.Error("[Gateway]",
+		zap.String("uri", ginCtx.Request.URL.Path),
+		zap.String("grpcCode", st.Code().String()),
+		zap.Error(err))
+
+	ginCtx.Set(gatewayHandled, true)
+	ginCtx.Set(controller.DewuCode, code)
+	ginCtx.JSON(httpStatus, controller.Response{
+		TraceId: common.NewRequest().TraceId(ginCtx),
+		Code:    code,
+		Status:  code,
+		Msg:     msg,
+		Data:    nil,
+	})
+}
+
+// GrpcCodeToStatus maps a gRPC code to the Code/Status of controller.Response.
+func GrpcCodeToStatus(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.InvalidArgument, codes.OutOfRange, codes.FailedPrecondition:
+		return controller.ParamsCode
+	default:
+		return runtime.HTTPStatusFromCode(code)
+	}
+}
+
+// gatewayCredentials matches the transport of the local gRPC server. With TLS
+// the server certificate is verified against CAFile (system roots without
+// one) and ServerName; a loopback endpoint without CAFile trusts CertFile
+// itself. The client certificate is loaded once when the gateway dials.
+func (svc *GinServer) gatewayCredentials() (grpc.DialOption, error) {
+	cfg := svc.grpcTLS()
+	if svc.singlePort() {
+		cfg = svc.httpTLS()
+	}
+
+	if cfg == nil {
+		return grpc.WithInsecure(), nil
+	}
+
+	host, _, err := net.SplitHostPort(svc.grpcEndpoint())
+	if err != nil {
+		return nil, err
+	}
+
+	certFile, keyFile := cfg.ClientCertFile, cfg.ClientKeyFile
+	if certFile == "" {
+		certFile, keyFile = cfg.CertFile, cfg.KeyFile
+	}
+
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("tls: load client certificate %s: %w", certFile, err)
+	}
+
+	tlsCfg := &tls.Config{
+		MinVersion:   tlsMinVersion,
+		ServerName:   cfg.ServerName,
+		Certificates: []tls.Certificate{cert},
+	}
+
+	if tlsCfg.ServerName == "" {
+		tlsCfg.ServerName = host
+	}
+
+	switch {
+	case cfg.CAFile != "":
+		if tlsCfg.RootCAs, err = loadCertPool(cfg.CAFile); err != nil {
+			return nil, err
+		}
+	case isLoopback(host):
+		if tlsCfg.RootCAs, err = loadCertPool(cfg.CertFile); err != nil {
+			return nil, err
+		}
+
+		if cfg.ServerName == "" {
+			tlsCfg.ServerName, err = pinnedServerName(cfg.CertFile, host)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return grpc.WithTransportCredentials(credentials.NewTLS(tlsCfg)), nil
+}
+
+func loadCertPool(file string) (*x509.CertPool, error) {
+	body, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("tls: read ca %s: %w", file, err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(body) {
+		return nil, fmt.Errorf("tls: no certificate found in ca %s", file)
+	}
+
+	return pool, nil
+}
+
+// pinnedServerName returns host if the certificate in file is valid for it,
+// otherwise the first name of the certificate.
+func pinnedServerName(file, host string) (string, error) {
+	body, err := ioutil.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("tls: read certificate %s: %w", file, err)
+	}
+
+	block, _ := pem.Decode(body)
+	if block == nil {
+		return "", fmt.Errorf("tls: no certificate found in %s", file)
+	}
+
+	leaf, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return "", fmt.Errorf("tls: parse certificate %s: %w", file, err)
+	}
+
+	if leaf.VerifyHostname(host) == nil || len(leaf.DNSNames) == 0 {
+		return host, nil
+	}
+
+	return leaf.DNSNames[0], nil
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func (svc *GinServer) grpcEndpoint() string {
+	host := svc.ServerCfg.Host
+	if host == "" || host == "0.0.0.0" || host == "::" || host == "[::]" {
+		host = "127.0.0.1"
+	}
+
+	port := svc.ServerCfg.GrpcPort
+	if port <= 0 {
+		port = GrpcPortDefault
+	}
+
+	if svc.singlePort() {
+		port = svc.ServerCfg.Port
+	}
+
+	return fmt.Sprintf("%s:%d", host, port)
+}
+
+func (w *gatewayWriter) WriteHeader(code int) {
+	if w.streaming {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+
+	w.status = code
+}
+
+func (w *gatewayWriter) Write(b []byte) (int, error) {
+	if w.streaming {
+		return w.ResponseWriter.Write(b)
+	}
+
+	return w.buf.Write(b)
+}
+
+func (w *gatewayWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *gatewayWriter) Flush() {
+	if !w.streaming {
+		w.streaming = true
+		w.ResponseWriter.WriteHeader(w.status)
+		w.ResponseWriter.Write(w.buf.Bytes())
+		w.buf.Reset()
+	}
+
+	w.ResponseWriter.Flush()
+}
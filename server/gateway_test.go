@@ -0,0 +1,119 @@
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	grpc_health "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// writeCert writes a self-signed certificate for name into dir.
+func writeCert(t *testing.T, dir, name string) *TLSConfig {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &TLSConfig{CertFile: filepath.Join(dir, name+".crt"), KeyFile: filepath.Join(dir, name+".key")}
+	if err := os.WriteFile(cfg.CertFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(cfg.KeyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return cfg
+}
+
+func TestGatewayCredentialsPinLoopback(t *testing.T) {
+	dir := t.TempDir()
+	own := writeCert(t, dir, "grpc.internal")
+	other := writeCert(t, dir, "other.internal")
+
+	tests := []struct {
+		name  string
+		serve *TLSConfig
+		fails bool
+	}{
+		{"own certificate", own, false},
+		{"other certificate", other, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cert, err := tls.LoadX509KeyPair(tt.serve.CertFile, tt.serve.KeyFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			s := grpc.NewServer(grpc.Creds(credentials.NewServerTLSFromCert(&cert)))
+			healthpb.RegisterHealthServer(s, grpc_health.NewServer())
+			go s.Serve(ln)
+			defer s.Stop()
+
+			svc := &GinServer{
+				ServerCfg:  &Config{Host: "127.0.0.1", GrpcPort: ln.Addr().(*net.TCPAddr).Port, GrpcTLS: own},
+				GrpcServer: &GrpcServer{},
+			}
+
+			creds, err := svc.gatewayCredentials()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			conn, err := grpc.Dial(svc.grpcEndpoint(), creds)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer conn.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+
+			_, err = healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+			if (err != nil) != tt.fails {
+				t.Fatalf("Check() = %v, fails %v", err, tt.fails)
+			}
+		})
+	}
+}
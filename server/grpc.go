@@ -1,14 +1,17 @@
 package server
 
 import (
-	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
-	grpc_recovery "github.com/grpc-ecosystem/go-grpc-middleware/recovery"
-	grpc_ctxtags "github.com/grpc-ecosystem/go-grpc-middleware/tags"
-	grpc_opentracing "github.com/grpc-ecosystem/go-grpc-middleware/tracing/opentracing"
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
 	grpc_prometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
 	"google.golang.org/grpc"
+	grpc_health "google.golang.org/grpc/health"
 	"google.golang.org/grpc/reflection"
-	"net"
 )
 
 type (
@@ -16,35 +19,103 @@ type (
 		Server            *grpc.Server
 		Listener          net.Listener
 		RegisteGrpcServer func(*grpc.Server)
+		Health            *grpc_health.Server
+		healthDone        chan struct{}
+		healthStop        sync.Once
+		httpStreams       int64
+		options           grpcOptions
 	}
 )
 
-func NewGrpcServer() *GrpcServer {
-	return &GrpcServer{}
+func NewGrpcServer(opts ...GrpcOption) *GrpcServer {
+	return (&GrpcServer{}).Options(opts...)
+}
+
+// Options applies opts; it must be called before the server is started.
+func (svc *GrpcServer) Options(opts ...GrpcOption) *GrpcServer {
+	for _, opt := range opts {
+		opt(&svc.options)
+	}
+
+	return svc
 }
 
 func (svc *GrpcServer) RunGrpcServe() error {
+	return svc.NewServer().Serve()
+}
+
+func (svc *GrpcServer) NewServer() *GrpcServer {
 	grpc_prometheus.EnableHandlingTimeHistogram()
 
-	svc.Server = grpc.NewServer(
-		grpc.UnaryInterceptor(grpc_middleware.ChainUnaryServer(
-			grpc_ctxtags.UnaryServerInterceptor(),
-			grpc_opentracing.UnaryServerInterceptor(),
-			grpc_prometheus.UnaryServerInterceptor,
-			grpc_recovery.UnaryServerInterceptor(),
-		)),
-
-		grpc.StreamInterceptor(grpc_middleware.ChainStreamServer(
-			grpc_ctxtags.StreamServerInterceptor(),
-			grpc_opentracing.StreamServerInterceptor(),
-			grpc_prometheus.StreamServerInterceptor,
-		)),
-	)
+	svc.Server = grpc.NewServer(svc.options.build()...)
 
 	svc.RegisteGrpcServer(svc.Server)
+	svc.registerHealth()
 
 	reflection.Register(svc.Server)
 
 	grpc_prometheus.Register(svc.Server)
+	return svc
+}
+
+func (svc *GrpcServer) Serve() error {
 	return svc.Server.Serve(svc.Listener)
 }
+
+// GracefulStop waits for pending RPCs to finish, forcing the server to stop
+// once ctx is done.
+func (svc *GrpcServer) GracefulStop(ctx context.Context) error {
+	if svc.Server == nil {
+		return nil
+	}
+
+	svc.stopHealth()
+
+	done := make(chan struct{})
+	go func() {
+		svc.Server.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		svc.Server.Stop()
+		return ctx.Err()
+	}
+}
+
+// ServeHTTP serves a gRPC request received by the HTTP server in single-port
+// mode, keeping count of the streams still running.
+func (svc *GrpcServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	atomic.AddInt64(&svc.httpStreams, 1)
+	defer atomic.AddInt64(&svc.httpStreams, -1)
+
+	svc.Server.ServeHTTP(w, r)
+}
+
+// StopHTTP stops a server whose RPCs come through ServeHTTP. GracefulStop
+// cannot drain those streams, so it waits for them to finish and closes the
+// remaining ones once ctx is done.
+func (svc *GrpcServer) StopHTTP(ctx context.Context) error {
+	if svc.Server == nil {
+		return nil
+	}
+
+	svc.stopHealth()
+	defer svc.Server.Stop()
+
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+
+	for atomic.LoadInt64(&svc.httpStreams) > 0 {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
@@ -2,6 +2,7 @@ package server
 
 import (
 	"context"
+
 	grpc_recovery "github.com/grpc-ecosystem/go-grpc-middleware/recovery"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -9,9 +10,15 @@ import (
 )
 
 func UnaryServerRecovery() grpc.UnaryServerInterceptor {
-	return grpc_recovery.UnaryServerInterceptor(grpc_recovery.WithRecoveryHandlerContext(func(ctx context.Context, p interface{}) (err error) {
-		LogRecoverStack(p)
-		err = status.Errorf(codes.Internal, "%s", p)
-		return err
-	}))
+	return grpc_recovery.UnaryServerInterceptor(grpc_recovery.WithRecoveryHandlerContext(recoveryHandler))
+}
+
+func StreamServerRecovery() grpc.StreamServerInterceptor {
+	return grpc_recovery.StreamServerInterceptor(grpc_recovery.WithRecoveryHandlerContext(recoveryHandler))
+}
+
+func recoveryHandler(ctx context.Context, p interface{}) (err error) {
+	LogRecoverStack(p)
+	err = status.Errorf(codes.Internal, "%s", p)
+	return err
 }
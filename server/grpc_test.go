@@ -0,0 +1,66 @@
+package server
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"golang.org/x/text/language"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestSinglePortShutdownWithOpenStream(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	svc := &GinServer{
+		ServerCfg:  &Config{SinglePort: true},
+		Engine:     gin.New(),
+		I18nBundle: i18n.NewBundle(language.English),
+		GrpcServer: &GrpcServer{RegisteGrpcServer: func(*grpc.Server) {}},
+		exit:       make(chan chan error),
+	}
+	svc.Grpc()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	svc.HttpServer = &http.Server{Handler: svc.httpHandler()}
+	go svc.serveHttp(ln)
+
+	conn, err := grpc.Dial(ln.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	stream, err := healthpb.NewHealthClient(conn).Watch(context.Background(), &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := stream.Recv(); err != nil {
+		t.Fatalf("Recv() = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	// the watch stream never ends on its own, so shutdown has to close it
+	if err := svc.shutdown(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("shutdown() = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	for {
+		if _, err := stream.Recv(); err != nil {
+			break
+		}
+	}
+}
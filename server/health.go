@@ -0,0 +1,87 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopastro/go-common/controller"
+	"github.com/shopastro/go-common/health"
+	grpc_health "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const (
+	healthLivePath      = "/health/live"
+	healthReadyPath     = "/health/ready"
+	healthCheckInterval = 5 * time.Second
+)
+
+func (svc *GinServer) healthRoute() {
+	svc.Engine.GET(defaultHealthPath, healthHandler(health.Live))
+	svc.Engine.GET(healthLivePath, healthHandler(health.Live))
+	svc.Engine.GET(healthReadyPath, healthHandler(health.Ready))
+}
+
+func healthHandler(probe func(ctx context.Context) *health.Report) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		report := probe(ctx.Request.Context())
+
+		code, msg := http.StatusOK, "health ok"
+		if !report.IsUp() {
+			code, msg = http.StatusServiceUnavailable, "health fail"
+		}
+
+		ctx.JSON(code, &controller.Response{
+			Code:   code,
+			Status: code,
+			Msg:    msg,
+			Data:   report,
+		})
+	}
+}
+
+func (svc *GrpcServer) registerHealth() {
+	svc.Health = grpc_health.NewServer()
+	healthpb.RegisterHealthServer(svc.Server, svc.Health)
+
+	svc.healthDone = make(chan struct{})
+	go svc.watchHealth()
+}
+
+// watchHealth mirrors the readiness report into the grpc.health.v1 service,
+// for the whole server ("") and for every registered service.
+func (svc *GrpcServer) watchHealth() {
+	ticker := time.NewTicker(healthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		status := healthpb.HealthCheckResponse_SERVING
+		if !health.Ready(context.Background()).IsUp() {
+			status = healthpb.HealthCheckResponse_NOT_SERVING
+		}
+
+		svc.Health.SetServingStatus("", status)
+		for name := range svc.Server.GetServiceInfo() {
+			svc.Health.SetServingStatus(name, status)
+		}
+
+		select {
+		case <-svc.healthDone:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (svc *GrpcServer) stopHealth() {
+	if svc.Health == nil {
+		return
+	}
+
+	svc.healthStop.Do(func() {
+		close(svc.healthDone)
+		svc.Health.Shutdown()
+	})
+}
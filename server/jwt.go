@@ -0,0 +1,224 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopastro/go-common/common"
+	"github.com/shopastro/go-common/controller"
+	"github.com/shopastro/go-common/jwt"
+	"github.com/shopastro/logs"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type (
+	JWTOption func(*jwtOptions)
+
+	jwtOptions struct {
+		guest   bool
+		scopes  []string
+		methods map[string][]JWTOption
+	}
+
+	userInfoKey struct{}
+)
+
+var ErrInsufficientScope = errors.New("jwt: insufficient scope")
+
+// AllowGuest lets requests without a token through as guests, an invalid
+// token is still rejected.
+func AllowGuest() JWTOption {
+	return func(o *jwtOptions) {
+		o.guest = true
+	}
+}
+
+func RequireScopes(scopes ...string) JWTOption {
+	return func(o *jwtOptions) {
+		o.scopes = append(o.scopes, scopes...)
+	}
+}
+
+// ForMethod overrides the options of one gRPC method, e.g.
+// "/pkg.Service/Method".
+func ForMethod(fullMethod string, opts ...JWTOption) JWTOption {
+	return func(o *jwtOptions) {
+		if o.methods == nil {
+			o.methods = make(map[string][]JWTOption)
+		}
+
+		o.methods[fullMethod] = append(o.methods[fullMethod], opts...)
+	}
+}
+
+func newJWTOptions(opts []JWTOption) *jwtOptions {
+	o := &jwtOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+func (o *jwtOptions) forMethod(fullMethod string) *jwtOptions {
+	extra, ok := o.methods[fullMethod]
+	if !ok {
+		return o
+	}
+
+	m := &jwtOptions{guest: o.guest, scopes: append([]string{}, o.scopes...)}
+	for _, opt := range extra {
+		opt(m)
+	}
+
+	return m
+}
+
+// authenticate returns nil claims for an allowed guest.
+func (o *jwtOptions) authenticate(v *jwt.Validator, token string) (*jwt.Claims, error) {
+	if token == "" {
+		if o.guest && len(o.scopes) == 0 {
+			return nil, nil
+		}
+
+		return nil, jwt.ErrTokenMissing
+	}
+
+	claims, err := v.Parse(token)
+	if err != nil {
+		return nil, err
+	}
+
+	if !claims.HasScopes(o.scopes...) {
+		return claims, ErrInsufficientScope
+	}
+
+	return claims, nil
+}
+
+func userInfo(claims *jwt.Claims) controller.UserInfoHeader {
+	if claims == nil {
+		return controller.UserInfoHeader{IsGuest: true}
+	}
+
+	return controller.UserInfoHeader{
+		Uuid:    claims.Uuid,
+		UserId:  claims.User(),
+		IsGuest: claims.IsGuest,
+	}
+}
+
+// JWTHandler validates the token in X-Auth-Token or Authorization, puts the
+// claims under controller.StandardClaimsName and the user under
+// controller.UserInfoName.
+func JWTHandler(v *jwt.Validator, opts ...JWTOption) gin.HandlerFunc {
+	o := newJWTOptions(opts)
+
+	return func(ctx *gin.Context) {
+		token := ctx.GetHeader(controller.TokenName)
+		if token == "" {
+			token = ctx.GetHeader("Authorization")
+		}
+
+		claims, err := o.authenticate(v, jwt.TrimBearer(token))
+		if err != nil {
+			code := http.StatusUnauthorized
+			if errors.Is(err, ErrInsufficientScope) {
+				code = http.StatusForbidden
+			}
+
+			logs.Logger.Error("[JWTHandler]",
+				zap.String("uri", ctx.Request.RequestURI),
+				zap.Error(err))
+
+			ctx.Set(controller.DewuCode, code)
+			ctx.AbortWithStatusJSON(code, controller.Response{
+				TraceId: common.NewRequest().TraceId(ctx),
+				Code:    code,
+				Status:  code,
+				Msg:     http.StatusText(code),
+			})
+			return
+		}
+
+		user := userInfo(claims)
+		c := context.WithValue(ctx.Request.Context(), userInfoKey{}, user)
+		if claims != nil {
+			ctx.Set(controller.StandardClaimsName, claims)
+			c = jwt.NewContext(c, claims)
+		}
+
+		ctx.Set(controller.UserInfoName, user)
+		ctx.Request = ctx.Request.WithContext(c)
+		ctx.Next()
+	}
+}
+
+// UserInfoFromContext returns the user set by JWTHandler or the JWT
+// interceptors.
+func UserInfoFromContext(ctx context.Context) (controller.UserInfoHeader, bool) {
+	user, ok := ctx.Value(userInfoKey{}).(controller.UserInfoHeader)
+	return user, ok
+}
+
+func UnaryServerJWT(v *jwt.Validator, opts ...JWTOption) grpc.UnaryServerInterceptor {
+	o := newJWTOptions(opts)
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := o.forMethod(info.FullMethod).grpcAuthenticate(ctx, v)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+func StreamServerJWT(v *jwt.Validator, opts ...JWTOption) grpc.StreamServerInterceptor {
+	o := newJWTOptions(opts)
+
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := o.forMethod(info.FullMethod).grpcAuthenticate(ss.Context(), v)
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &identityStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+func (o *jwtOptions) grpcAuthenticate(ctx context.Context, v *jwt.Validator) (context.Context, error) {
+	var token string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for _, key := range []string{controller.TokenName, "authorization"} {
+			if values := md.Get(key); len(values) > 0 {
+				token = values[0]
+				break
+			}
+		}
+	}
+
+	claims, err := o.authenticate(v, jwt.TrimBearer(token))
+	if err != nil {
+		logs.Logger.Error("[GrpcJWT]", zap.Error(err))
+
+		if errors.Is(err, ErrInsufficientScope) {
+			return ctx, status.Error(codes.PermissionDenied, http.StatusText(http.StatusForbidden))
+		}
+
+		return ctx, status.Error(codes.Unauthenticated, http.StatusText(http.StatusUnauthorized))
+	}
+
+	ctx = context.WithValue(ctx, userInfoKey{}, userInfo(claims))
+	if claims != nil {
+		ctx = jwt.NewContext(ctx, claims)
+	}
+
+	return ctx, nil
+}
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopastro/go-common/controller"
+	"github.com/shopastro/go-common/jwt"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestJWTErrorsAreGeneric(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	v, err := jwt.NewValidator(&jwt.Config{Secret: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expired, err := jwt.Sign(&jwt.Claims{Subject: "7", ExpiresAt: time.Now().Add(-time.Hour).Unix()}, jwt.HS256, "", []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"expired", controller.BearerTokenPrefix + expired},
+		{"malformed", "a.b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := gin.New()
+			engine.GET("/", JWTHandler(v))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Authorization", tt.token)
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+
+			var resp controller.Response
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+
+			if w.Code != http.StatusUnauthorized || resp.Msg != http.StatusText(http.StatusUnauthorized) {
+				t.Fatalf("JWTHandler() = %d %q", w.Code, resp.Msg)
+			}
+
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", tt.token))
+			_, err := newJWTOptions(nil).grpcAuthenticate(ctx, v)
+			if msg := status.Convert(err).Message(); msg != http.StatusText(http.StatusUnauthorized) {
+				t.Fatalf("grpcAuthenticate() = %q", msg)
+			}
+		})
+	}
+}
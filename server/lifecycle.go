@@ -0,0 +1,171 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/shopastro/go-common/shutdown"
+	"github.com/shopastro/logs"
+	"go.uber.org/zap"
+)
+
+type (
+	ShutdownHook func(ctx context.Context) error
+
+	shutdownHook struct {
+		name string
+		fn   ShutdownHook
+	}
+)
+
+const (
+	ShutdownTimeoutDefault = 10 * time.Second
+)
+
+// RegisterShutdownHook registers a hook that runs after the HTTP and gRPC
+// listeners are drained. Hooks run in reverse registration order, before the
+// mysql, redis and tracer close hooks those components register themselves.
+func (svc *GinServer) RegisterShutdownHook(name string, fn ShutdownHook) *GinServer {
+	svc.rw.Lock()
+	defer svc.rw.Unlock()
+
+	svc.hooks = append(svc.hooks, shutdownHook{name: name, fn: fn})
+
+	return svc
+}
+
+// Start runs the HTTP and gRPC servers, registers the instance when a
+// Registry is set once the HTTP listener is bound, and blocks until SIGTERM/SIGINT is received, ctx is done,
+// Stop is called or the HTTP server fails. It then deregisters and shuts
+// everything down gracefully.
+func (svc *GinServer) Start(ctx context.Context) error {
+	svc.Run()
+
+	ln, err := svc.newHttpServer().listenHttp()
+	if err != nil {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), svc.shutdownTimeout())
+		defer cancel()
+
+		svc.shutdown(shutdownCtx)
+		return err
+	}
+
+	httpErr := make(chan error, 1)
+	go func() {
+		httpErr <- svc.serveHttp(ln)
+	}()
+
+	regCtx, regCancel := context.WithCancel(context.Background())
+	defer regCancel()
+	svc.register(regCtx)
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGTERM, syscall.SIGINT)
+	defer signal.Stop(sig)
+
+	var (
+		ch     chan error
+		runErr error
+	)
+
+	select {
+	case runErr = <-httpErr:
+		logs.Logger.Error("[GinServer] http server exited", zap.Error(runErr))
+	case s := <-sig:
+		logs.Logger.Info("[GinServer] received signal", zap.String("signal", s.String()))
+	case <-ctx.Done():
+		logs.Logger.Info("[GinServer] context done", zap.Error(ctx.Err()))
+	case ch = <-svc.exit:
+	}
+
+	regCancel()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), svc.shutdownTimeout())
+	defer cancel()
+
+	err = svc.shutdown(shutdownCtx)
+	if ch != nil {
+		ch <- err
+	}
+
+	if runErr != nil {
+		return runErr
+	}
+
+	return err
+}
+
+// Stop asks a running Start to shut down and waits for it to finish.
+func (svc *GinServer) Stop(ctx context.Context) error {
+	ch := make(chan error, 1)
+
+	select {
+	case svc.exit <- ch:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case err := <-ch:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (svc *GinServer) shutdown(ctx context.Context) error {
+	var first error
+	record := func(step string, err error) {
+		if err == nil {
+			return
+		}
+
+		logs.Logger.Error("[GinServer shutdown]", zap.String("step", step), zap.Error(err))
+		if first == nil {
+			first = err
+		}
+	}
+
+	record("registry", svc.deregister(ctx))
+
+	if svc.HttpServer != nil {
+		err := svc.HttpServer.Shutdown(ctx)
+		if err == http.ErrServerClosed {
+			err = nil
+		}
+		record("http", err)
+	}
+
+	if svc.singlePort() {
+		record("grpc", svc.GrpcServer.StopHTTP(ctx))
+	} else {
+		record("grpc", svc.GrpcServer.GracefulStop(ctx))
+	}
+
+	svc.rw.RLock()
+	hooks := make([]shutdownHook, len(svc.hooks))
+	copy(hooks, svc.hooks)
+	svc.rw.RUnlock()
+
+	for i := len(hooks) - 1; i >= 0; i-- {
+		record(hooks[i].name, hooks[i].fn(ctx))
+	}
+
+	shutdown.Run(ctx, record)
+
+	svc.stopReloaders()
+
+	return first
+}
+
+func (svc *GinServer) shutdownTimeout() time.Duration {
+	if svc.ServerCfg.ShutdownTimeout <= 0 {
+		return ShutdownTimeoutDefault
+	}
+
+	return svc.ServerCfg.ShutdownTimeout * time.Millisecond
+}
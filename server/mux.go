@@ -0,0 +1,34 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+// singlePort reports whether gRPC is served on the HTTP listener.
+func (svc *GinServer) singlePort() bool {
+	return svc.ServerCfg.SinglePort && svc.GrpcServer.RegisteGrpcServer != nil
+}
+
+// httpHandler routes HTTP/2 application/grpc requests to the gRPC server and
+// everything else to gin when running in single port mode. Cleartext HTTP/2
+// is accepted through h2c.
+func (svc *GinServer) httpHandler() http.Handler {
+	if !svc.singlePort() {
+		return svc.Engine
+	}
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+			svc.GrpcServer.ServeHTTP(w, r)
+			return
+		}
+
+		svc.Engine.ServeHTTP(w, r)
+	})
+
+	return h2c.NewHandler(handler, &http2.Server{})
+}
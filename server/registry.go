@@ -0,0 +1,170 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/shopastro/go-common/addr"
+	xnet "github.com/shopastro/go-common/net"
+	"github.com/shopastro/go-common/registry"
+	"github.com/shopastro/logs"
+	"go.uber.org/zap"
+)
+
+// The registry contracts live in package registry so clients can use them
+// without depending on server.
+type (
+	ServiceInstance = registry.ServiceInstance
+	Registry        = registry.Registry
+	Watcher         = registry.Watcher
+)
+
+const (
+	RegistryTTLDefault      = 30 * time.Second
+	RegistryIntervalDefault = 10 * time.Second
+)
+
+func (svc *GinServer) SetRegistry(r Registry) *GinServer {
+	svc.Registry = r
+
+	return svc
+}
+
+func (svc *GinServer) register(ctx context.Context) {
+	if svc.Registry == nil {
+		return
+	}
+
+	ins, err := svc.newServiceInstance()
+	if err != nil {
+		logs.Logger.Error("[Registry] build service instance", zap.Error(err))
+		return
+	}
+
+	svc.rw.Lock()
+	svc.instance = ins
+	svc.rw.Unlock()
+
+	ttl, interval := svc.registryTTL(), svc.registryInterval()
+	if interval >= ttl {
+		logs.Logger.Error("[Registry] interval must be less than ttl, using ttl/3",
+			zap.Duration("interval", interval),
+			zap.Duration("ttl", ttl))
+		interval = ttl / 3
+	}
+
+	if err := svc.Registry.Register(ctx, ins, ttl); err != nil {
+		logs.Logger.Error("[Registry] register", zap.String("id", ins.Id), zap.Error(err))
+	} else {
+		svc.rw.Lock()
+		svc.registered = true
+		svc.rw.Unlock()
+	}
+
+	done := make(chan struct{})
+	svc.rw.Lock()
+	svc.refreshDone = done
+	svc.rw.Unlock()
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := svc.Registry.Register(ctx, ins, ttl); err != nil {
+					logs.Logger.Error("[Registry] refresh", zap.String("id", ins.Id), zap.Error(err))
+					continue
+				}
+
+				svc.rw.Lock()
+				svc.registered = true
+				svc.rw.Unlock()
+			}
+		}
+	}()
+}
+
+// deregister waits for a refresh in flight, the register context must be
+// cancelled before, so the instance is not registered again afterwards.
+func (svc *GinServer) deregister(ctx context.Context) error {
+	svc.rw.RLock()
+	done := svc.refreshDone
+	svc.rw.RUnlock()
+
+	if done != nil {
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	svc.rw.Lock()
+	ins, registered := svc.instance, svc.registered
+	svc.registered = false
+	svc.rw.Unlock()
+
+	if svc.Registry == nil || ins == nil || !registered {
+		return nil
+	}
+
+	return svc.Registry.Deregister(ctx, ins)
+}
+
+func (svc *GinServer) newServiceInstance() (*ServiceInstance, error) {
+	host, err := addr.Extract(svc.ServerCfg.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoints := []string{
+		xnet.NewEndpoint("http", xnet.HostPort(host, svc.ServerCfg.Port), false).String(),
+	}
+
+	if svc.GrpcServer.Listener != nil {
+		grpcAddr, err := xnet.Extract(fmt.Sprintf("%s:%d", svc.ServerCfg.Host, svc.ServerCfg.GrpcPort), svc.GrpcServer.Listener)
+		if err != nil {
+			return nil, err
+		}
+
+		endpoints = append(endpoints, xnet.NewEndpoint("grpc", grpcAddr, false).String())
+	} else if svc.singlePort() {
+		endpoints = append(endpoints, xnet.NewEndpoint("grpc", xnet.HostPort(host, svc.ServerCfg.Port), false).String())
+	}
+
+	md := make(map[string]string, len(svc.Metadata))
+	for k, v := range svc.Metadata {
+		md[k] = v
+	}
+
+	return &ServiceInstance{
+		Id:        svc.Id,
+		Name:      svc.String(),
+		Namespace: svc.ServerCfg.Namespace,
+		Metadata:  md,
+		Endpoints: endpoints,
+	}, nil
+}
+
+func (svc *GinServer) registryTTL() time.Duration {
+	if svc.ServerCfg.TTL <= 0 {
+		return RegistryTTLDefault
+	}
+
+	return svc.ServerCfg.TTL * time.Millisecond
+}
+
+func (svc *GinServer) registryInterval() time.Duration {
+	if svc.ServerCfg.Interval <= 0 {
+		return RegistryIntervalDefault
+	}
+
+	return svc.ServerCfg.Interval * time.Millisecond
+}
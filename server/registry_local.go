@@ -0,0 +1,274 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+)
+
+type (
+	// LocalRegistry keeps instances in memory and, when created with a path,
+	// mirrors them to a JSON file so processes on the same host can share it.
+	// Writes hold a lock file and replace the file atomically. It is meant for
+	// tests and local development, not production.
+	LocalRegistry struct {
+		mu        sync.Mutex
+		path      string
+		raw       []byte
+		instances map[string]*localEntry
+		watchers  map[*localWatcher]struct{}
+	}
+
+	localEntry struct {
+		Instance *ServiceInstance `json:"instance"`
+		ExpireAt time.Time        `json:"expireAt"`
+	}
+
+	localWatcher struct {
+		ctx       context.Context
+		cancel    context.CancelFunc
+		registry  *LocalRegistry
+		namespace string
+		name      string
+		event     chan struct{}
+		last      []*ServiceInstance
+		first     bool
+	}
+)
+
+const (
+	localRegistryPollInterval = time.Second
+)
+
+func NewMemoryRegistry() *LocalRegistry {
+	return &LocalRegistry{
+		instances: make(map[string]*localEntry),
+		watchers:  make(map[*localWatcher]struct{}),
+	}
+}
+
+func NewFileRegistry(path string) *LocalRegistry {
+	r := NewMemoryRegistry()
+	r.path = path
+
+	return r
+}
+
+func (r *LocalRegistry) Register(ctx context.Context, ins *ServiceInstance, ttl time.Duration) error {
+	return r.update(func() {
+		r.instances[instanceKey(ins.Namespace, ins.Name, ins.Id)] = &localEntry{
+			Instance: ins,
+			ExpireAt: time.Now().Add(ttl),
+		}
+	})
+}
+
+func (r *LocalRegistry) Deregister(ctx context.Context, ins *ServiceInstance) error {
+	return r.update(func() {
+		delete(r.instances, instanceKey(ins.Namespace, ins.Name, ins.Id))
+	})
+}
+
+// update applies fn to the latest content of the file while holding the
+// cross-process lock, so concurrent registrations are not lost.
+func (r *LocalRegistry) update(fn func()) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.path != "" {
+		unlock, err := lockFile(r.path)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+	}
+
+	if err := r.load(); err != nil {
+		return err
+	}
+
+	fn()
+
+	return r.save()
+}
+
+func (r *LocalRegistry) Watch(ctx context.Context, namespace, name string) (Watcher, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	w := &localWatcher{
+		ctx:       ctx,
+		cancel:    cancel,
+		registry:  r,
+		namespace: namespace,
+		name:      name,
+		event:     make(chan struct{}, 1),
+		first:     true,
+	}
+
+	r.mu.Lock()
+	r.watchers[w] = struct{}{}
+	r.mu.Unlock()
+
+	return w, nil
+}
+
+// GetService returns the live instances of a service.
+func (r *LocalRegistry) GetService(ctx context.Context, namespace, name string) ([]*ServiceInstance, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+
+	return r.list(namespace, name), nil
+}
+
+func (r *LocalRegistry) list(namespace, name string) []*ServiceInstance {
+	now := time.Now()
+	keys := make([]string, 0, len(r.instances))
+	for k, e := range r.instances {
+		if now.After(e.ExpireAt) {
+			delete(r.instances, k)
+			continue
+		}
+
+		if e.Instance.Namespace == namespace && e.Instance.Name == name {
+			keys = append(keys, k)
+		}
+	}
+
+	sort.Strings(keys)
+	list := make([]*ServiceInstance, 0, len(keys))
+	for _, k := range keys {
+		list = append(list, r.instances[k].Instance)
+	}
+
+	return list
+}
+
+func (r *LocalRegistry) load() error {
+	if r.path == "" {
+		return nil
+	}
+
+	body, err := ioutil.ReadFile(r.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// compare content rather than mtime, whose granularity can hide a write
+	if bytes.Equal(body, r.raw) {
+		return nil
+	}
+
+	instances := make(map[string]*localEntry)
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &instances); err != nil {
+			return err
+		}
+	}
+
+	r.instances = instances
+	r.raw = body
+	r.notify()
+
+	return nil
+}
+
+func (r *LocalRegistry) save() error {
+	defer r.notify()
+
+	if r.path == "" {
+		return nil
+	}
+
+	body, err := json.MarshalIndent(r.instances, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(r.path), filepath.Base(r.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(body); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), r.path); err != nil {
+		return err
+	}
+
+	r.raw = body
+
+	return nil
+}
+
+func (r *LocalRegistry) notify() {
+	for w := range r.watchers {
+		select {
+		case w.event <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (w *localWatcher) Next() ([]*ServiceInstance, error) {
+	ticker := time.NewTicker(localRegistryPollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		list, err := w.registry.GetService(w.ctx, w.namespace, w.name)
+		if err != nil {
+			return nil, err
+		}
+
+		if w.first || !reflect.DeepEqual(list, w.last) {
+			w.first = false
+			w.last = list
+			return list, nil
+		}
+
+		select {
+		case <-w.ctx.Done():
+			return nil, w.ctx.Err()
+		case <-w.event:
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *localWatcher) Stop() error {
+	w.cancel()
+
+	w.registry.mu.Lock()
+	delete(w.registry.watchers, w)
+	w.registry.mu.Unlock()
+
+	return nil
+}
+
+func instanceKey(namespace, name, id string) string {
+	return namespace + "/" + name + "/" + id
+}
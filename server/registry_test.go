@@ -0,0 +1,72 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// slowRegistry takes a while to register and ignores ctx, like a file
+// registry busy with its lock.
+type slowRegistry struct {
+	mu       sync.Mutex
+	calls    []string
+	inFlight chan struct{}
+}
+
+func (r *slowRegistry) Register(ctx context.Context, ins *ServiceInstance, ttl time.Duration) error {
+	r.record("register-start")
+	select {
+	case r.inFlight <- struct{}{}:
+	default:
+	}
+	time.Sleep(30 * time.Millisecond)
+	r.record("register")
+	return nil
+}
+
+func (r *slowRegistry) Deregister(ctx context.Context, ins *ServiceInstance) error {
+	r.record("deregister")
+	return nil
+}
+
+func (r *slowRegistry) Watch(ctx context.Context, namespace, name string) (Watcher, error) {
+	return nil, nil
+}
+
+func (r *slowRegistry) record(call string) {
+	r.mu.Lock()
+	r.calls = append(r.calls, call)
+	r.mu.Unlock()
+}
+
+func TestDeregisterWaitsForRefresh(t *testing.T) {
+	r := &slowRegistry{inFlight: make(chan struct{}, 1)}
+	svc := &GinServer{
+		ServerCfg:  &Config{Host: "127.0.0.1", Port: 8000, TTL: 300, Interval: 10},
+		GrpcServer: &GrpcServer{},
+		Registry:   r,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	svc.register(ctx)
+
+	// wait for a refresh to be in flight, then shut down
+	<-r.inFlight
+	<-r.inFlight
+	cancel()
+
+	if err := svc.deregister(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if last := r.calls[len(r.calls)-1]; last != "deregister" {
+		t.Fatalf("calls = %v, want deregister last", r.calls)
+	}
+}
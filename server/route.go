@@ -0,0 +1,209 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopastro/go-common/common"
+	"github.com/shopastro/go-common/controller"
+	"github.com/shopastro/go-common/limiter"
+	"github.com/shopastro/logs"
+	"go.uber.org/zap"
+)
+
+type (
+	// RouteRegistrar adds its routes to group, through GinServer.AddRoutes
+	// to record their metadata.
+	RouteRegistrar interface {
+		Routes(group *gin.RouterGroup)
+	}
+
+	Route struct {
+		Name        string
+		Method      string
+		Path        string
+		Handler     gin.HandlerFunc
+		Middlewares []gin.HandlerFunc
+		// RateLimit is the number of requests allowed per second, Burst the
+		// bucket size; zero disables the limit.
+		RateLimit float64
+		Burst     int
+	}
+
+	RouteInfo struct {
+		Name      string  `json:"name"`
+		Method    string  `json:"method"`
+		Path      string  `json:"path"`
+		Handler   string  `json:"handler"`
+		RateLimit float64 `json:"rateLimit,omitempty"`
+		Burst     int     `json:"burst,omitempty"`
+	}
+
+	routeTable struct {
+		mu       sync.RWMutex
+		routes   map[string]*RouteInfo
+		limiters *limiter.LimiterStore
+	}
+)
+
+const (
+	defaultRoutesPath = "/admin/routes"
+)
+
+func newRouteTable() *routeTable {
+	return &routeTable{
+		routes:   make(map[string]*RouteInfo),
+		limiters: limiter.NewLimiterStore(),
+	}
+}
+
+// Register calls Routes of every registrar, in order, on RouterGroup.
+func (svc *GinServer) Register(registrars ...RouteRegistrar) *GinServer {
+	for _, r := range registrars {
+		r.Routes(svc.RouterGroup)
+	}
+
+	return svc
+}
+
+// AddRoutes registers routes on group and records their metadata so they
+// show up in Routes and on the admin endpoint.
+func (svc *GinServer) AddRoutes(group *gin.RouterGroup, rs ...Route) {
+	for _, r := range rs {
+		method := strings.ToUpper(r.Method)
+		fullPath := joinPath(group.BasePath(), r.Path)
+
+		name := r.Name
+		if name == "" {
+			name = method + " " + fullPath
+		}
+
+		handlers := make([]gin.HandlerFunc, 0, len(r.Middlewares)+2)
+		if r.RateLimit > 0 {
+			burst := r.Burst
+			if burst <= 0 {
+				burst = 1
+			}
+
+			key := routeKey(method, fullPath)
+			svc.routes.limiters.RegisterLimiter(key, r.RateLimit, burst)
+			handlers = append(handlers, svc.routes.rateLimitHandler(key))
+		}
+
+		handlers = append(handlers, r.Middlewares...)
+		handlers = append(handlers, r.Handler)
+		group.Handle(method, r.Path, handlers...)
+
+		svc.routes.mu.Lock()
+		svc.routes.routes[routeKey(method, fullPath)] = &RouteInfo{
+			Name:      name,
+			Method:    method,
+			Path:      fullPath,
+			RateLimit: r.RateLimit,
+			Burst:     r.Burst,
+		}
+		svc.routes.mu.Unlock()
+	}
+}
+
+// Routes lists every route on the engine, with the metadata recorded by
+// AddRoutes when available.
+func (svc *GinServer) Routes() []RouteInfo {
+	svc.routes.mu.RLock()
+	defer svc.routes.mu.RUnlock()
+
+	list := make([]RouteInfo, 0)
+	for _, r := range svc.Engine.Routes() {
+		info := RouteInfo{
+			Method:  r.Method,
+			Path:    r.Path,
+			Handler: r.Handler,
+		}
+
+		if meta, ok := svc.routes.routes[routeKey(r.Method, r.Path)]; ok {
+			info.Name = meta.Name
+			info.RateLimit = meta.RateLimit
+			info.Burst = meta.Burst
+		}
+
+		list = append(list, info)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Path == list[j].Path {
+			return list[i].Method < list[j].Method
+		}
+
+		return list[i].Path < list[j].Path
+	})
+
+	return list
+}
+
+// routesRoute lists the routes on the admin endpoint when Config.AdminRoutes
+// is set, behind AdminMiddlewares.
+func (svc *GinServer) routesRoute() {
+	if !svc.ServerCfg.AdminRoutes {
+		return
+	}
+
+	handlers := append(append([]gin.HandlerFunc{}, svc.AdminMiddlewares...), func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, &controller.Response{
+			Code:   http.StatusOK,
+			Status: http.StatusOK,
+			Data:   svc.Routes(),
+		})
+	})
+
+	svc.Engine.GET(defaultRoutesPath, handlers...)
+}
+
+func (svc *GinServer) logRoutes() {
+	for _, r := range svc.Routes() {
+		logs.Logger.Info("[Route]",
+			zap.String("name", r.Name),
+			zap.String("method", r.Method),
+			zap.String("path", r.Path),
+			zap.String("handler", r.Handler),
+			zap.Float64("rateLimit", r.RateLimit))
+	}
+}
+
+func (t *routeTable) rateLimitHandler(key string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if l := t.limiters.GetLimiter(key); l != nil && !l.Allow() {
+			ctx.Set(controller.DewuCode, http.StatusTooManyRequests)
+			ctx.AbortWithStatusJSON(http.StatusTooManyRequests, controller.Response{
+				TraceId: common.NewRequest().TraceId(ctx),
+				Code:    http.StatusTooManyRequests,
+				Status:  http.StatusTooManyRequests,
+				Msg:     http.StatusText(http.StatusTooManyRequests),
+			})
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+func joinPath(base, relative string) string {
+	if relative == "" {
+		return base
+	}
+
+	p := path.Join(base, relative)
+	if strings.HasSuffix(relative, "/") && !strings.HasSuffix(p, "/") {
+		return p + "/"
+	}
+
+	return p
+}
+
+func routeKey(method, fullPath string) string {
+	return fmt.Sprintf("%s %s", method, fullPath)
+}
@@ -21,44 +21,59 @@ import (
 	"github.com/urfave/cli"
 	"go.uber.org/zap"
 	"golang.org/x/text/language"
+	"google.golang.org/grpc"
 )
 
 type (
 	GinServer struct {
-		ServerCfg     *Config
-		Engine        *gin.Engine
-		tools         *common.Tools
-		RouterGroup   *gin.RouterGroup
-		I18nBundle    *i18n.Bundle
-		LoopCall      func(structs ...interface{})
-		RegisterRoute func()
-		GrpcServer    *GrpcServer
-		Tracer        opentracing.Tracer
-		CliCtx        *cli.Context
-		Metadata      map[string]string
-		registered    bool
-		rw            sync.RWMutex
-		exit          chan chan error
-		Id            string
+		ServerCfg        *Config
+		Engine           *gin.Engine
+		HttpServer       *http.Server
+		tools            *common.Tools
+		RouterGroup      *gin.RouterGroup
+		I18nBundle       *i18n.Bundle
+		LoopCall         func(structs ...interface{})
+		RegisterRoute    func()
+		GrpcServer       *GrpcServer
+		Tracer           opentracing.Tracer
+		CliCtx           *cli.Context
+		Metadata         map[string]string
+		Registry         Registry
+		instance         *ServiceInstance
+		registered       bool
+		refreshDone      chan struct{}
+		rw               sync.RWMutex
+		exit             chan chan error
+		hooks            []shutdownHook
+		reloaders        []*certReloader
+		routes           *routeTable
+		AdminMiddlewares []gin.HandlerFunc
+		Id               string
 	}
 
 	Config struct {
-		ContextPath string        `json:"contextPath" yaml:"contextPath"`
-		Host        string        `json:"host" yaml:"host"`
-		Port        int           `json:"port" yaml:"port"`
-		GrpcPort    int           `json:"grpcPort" yaml:"grpcPort"`
-		Mode        string        `json:"mode" yaml:"mode"`
-		Debug       bool          `json:"debug" yaml:"debug"`
-		TraceParam  float64       `json:"traceParam" yaml:"traceParam"`
-		Namespace   string        `json:"namespace" yaml:"namespace"`
-		TTL         time.Duration `json:"ttl" yaml:"ttl"`
-		Interval    time.Duration `json:"interval" yaml:"interval"`
-		HttpPort    int32         `json:"httpPort" yaml:"httpPort"`
+		ContextPath     string        `json:"contextPath" yaml:"contextPath"`
+		Host            string        `json:"host" yaml:"host"`
+		Port            int           `json:"port" yaml:"port"`
+		GrpcPort        int           `json:"grpcPort" yaml:"grpcPort"`
+		Mode            string        `json:"mode" yaml:"mode"`
+		Debug           bool          `json:"debug" yaml:"debug"`
+		TraceParam      float64       `json:"traceParam" yaml:"traceParam"`
+		Namespace       string        `json:"namespace" yaml:"namespace"`
+		TTL             time.Duration `json:"ttl" yaml:"ttl"`
+		Interval        time.Duration `json:"interval" yaml:"interval"`
+		HttpPort        int32         `json:"httpPort" yaml:"httpPort"`
+		ShutdownTimeout time.Duration `json:"shutdownTimeout" yaml:"shutdownTimeout"`
+		SinglePort      bool          `json:"singlePort" yaml:"singlePort"`
+		TLS             *TLSConfig    `json:"tls" yaml:"tls"`
+		GrpcTLS         *TLSConfig    `json:"grpcTls" yaml:"grpcTls"`
+		I18nDir         string        `json:"i18nDir" yaml:"i18nDir"`
+		AdminRoutes     bool          `json:"adminRoutes" yaml:"adminRoutes"`
 	}
 )
 
 const (
-	Localizer         = "Localizer"
+	Localizer         = controller.LocalizerName
 	Language          = "language"
 	HttpPortDefault   = 80
 	GrpcPortDefault   = 8080
@@ -76,7 +91,7 @@ func NewGinServer(cfg *Config, cliCtx *cli.Context) *GinServer {
 		tools:      common.NewTools(),
 		ServerCfg:  cfg,
 		Engine:     gin.New(),
-		I18nBundle: i18n.NewBundle(language.Chinese),
+		I18nBundle: i18n.NewBundle(language.English),
 		GrpcServer: NewGrpcServer(),
 		LoopCall: func(structs ...interface{}) {
 			for _, v := range structs {
@@ -85,7 +100,7 @@ func NewGinServer(cfg *Config, cliCtx *cli.Context) *GinServer {
 
 				for i := 0; i < classType.NumMethod(); i++ {
 					m := classValue.MethodByName(classType.Method(i).Name)
-					if m.IsValid() {
+					if m.IsValid() && m.Type().NumIn() == 0 {
 						var params []reflect.Value
 						m.Call(params)
 					}
@@ -98,11 +113,13 @@ func NewGinServer(cfg *Config, cliCtx *cli.Context) *GinServer {
 		}),
 		CliCtx: cliCtx,
 		exit:   make(chan chan error),
+		routes: newRouteTable(),
 		Id:     common.NewTools().GetRandomString(8),
 	}
 }
 
 func (svc *GinServer) Run() {
+	svc.loadI18n()
 	svc.Engine.Use(globally.NewRecoveryHandler().RecoveryWithLogger(!svc.ServerCfg.Debug))
 
 	if svc.ServerCfg.Debug {
@@ -115,6 +132,20 @@ func (svc *GinServer) Run() {
 func (svc *GinServer) Grpc() *GinServer {
 	defer globally.Recovers()
 
+	svc.GrpcServer.Options(
+		WithUnaryInterceptor(UnaryServerAppError(svc.I18nBundle)),
+		WithStreamInterceptor(StreamServerAppError(svc.I18nBundle)),
+	)
+
+	if svc.singlePort() {
+		if svc.httpTLS() != nil {
+			svc.GrpcServer.Options(WithUnaryInterceptor(UnaryServerIdentity()), WithStreamInterceptor(StreamServerIdentity()))
+		}
+
+		svc.GrpcServer.NewServer()
+		return svc
+	}
+
 	if svc.GrpcServer.RegisteGrpcServer != nil {
 		if svc.ServerCfg.GrpcPort <= 0 {
 			svc.ServerCfg.GrpcPort = GrpcPortDefault
@@ -129,8 +160,24 @@ func (svc *GinServer) Grpc() *GinServer {
 			logs.Logger.Fatal("[failed to listen]", zap.Any("error", err))
 		}
 
+		if cfg := svc.grpcTLS(); cfg != nil {
+			tlsCfg, err := svc.newTLSConfig(cfg, "h2")
+			if err != nil {
+				logs.Logger.Fatal("[Grpc TLS]", zap.Error(err))
+			}
+
+			svc.GrpcServer.Options(
+				WithTLSConfig(tlsCfg),
+				WithUnaryInterceptor(UnaryServerIdentity()),
+				WithStreamInterceptor(StreamServerIdentity()),
+			)
+		}
+
+		svc.GrpcServer.NewServer()
 		go func() {
-			logs.Logger.Fatal("[Grpc Server]", zap.Any("error", svc.GrpcServer.RunGrpcServe()))
+			if err := svc.GrpcServer.Serve(); err != nil && err != grpc.ErrServerStopped {
+				logs.Logger.Fatal("[Grpc Server]", zap.Any("error", err))
+			}
 		}()
 	}
 
@@ -138,14 +185,59 @@ func (svc *GinServer) Grpc() *GinServer {
 }
 
 func (svc *GinServer) RunHttpServe() error {
+	ln, err := svc.newHttpServer().listenHttp()
+	if err != nil {
+		return err
+	}
+
+	return svc.serveHttp(ln)
+}
+
+func (svc *GinServer) newHttpServer() *GinServer {
 	svc.newRoute().RegisterRoute()
+	svc.logRoutes()
 
 	if svc.ServerCfg.Port <= 0 {
 		svc.ServerCfg.Port = HttpPortDefault
 	}
 
 	httpAddr := fmt.Sprintf("%s:%d", svc.ServerCfg.Host, svc.ServerCfg.Port)
-	return svc.Engine.Run(httpAddr)
+	fmt.Println("http server: ", httpAddr)
+
+	svc.HttpServer = &http.Server{
+		Addr:    httpAddr,
+		Handler: svc.httpHandler(),
+	}
+
+	if cfg := svc.httpTLS(); cfg != nil {
+		tlsCfg, err := svc.newTLSConfig(cfg, "h2", "http/1.1")
+		if err != nil {
+			logs.Logger.Fatal("[Http TLS]", zap.Error(err))
+		}
+
+		svc.HttpServer.TLSConfig = tlsCfg
+	}
+
+	return svc
+}
+
+func (svc *GinServer) listenHttp() (net.Listener, error) {
+	return net.Listen("tcp", svc.HttpServer.Addr)
+}
+
+func (svc *GinServer) serveHttp(ln net.Listener) error {
+	var err error
+	if svc.HttpServer.TLSConfig != nil {
+		err = svc.HttpServer.ServeTLS(ln, "", "")
+	} else {
+		err = svc.HttpServer.Serve(ln)
+	}
+
+	if err != nil && err != http.ErrServerClosed {
+		return err
+	}
+
+	return nil
 }
 
 func (svc *GinServer) newRoute() *GinServer {
@@ -156,25 +248,21 @@ func (svc *GinServer) newRoute() *GinServer {
 		svc.ServerCfg.ContextPath,
 		tracer.NewTracerServer(svc.Tracer).MiddlewareTracerFunc,
 		svc.Localizer,
+		ClientIdentityHandler,
 		func(ctx *gin.Context) {
 			if svc.CliCtx != nil {
 				ctx.Set(controller.DewuReleaseVersion, svc.CliCtx.String("releaseVersion"))
 			}
 		})
 
-	svc.Engine.GET(defaultHealthPath, func(ctx *gin.Context) {
-		ctx.JSON(http.StatusOK, &controller.Response{
-			Code:   http.StatusOK,
-			Status: http.StatusOK,
-			Msg:    "health ok",
-		})
-	})
+	svc.healthRoute()
+	svc.routesRoute()
 
 	return svc
 }
 
 func (svc *GinServer) Localizer(ctx *gin.Context) {
-	localizer := i18n.NewLocalizer(svc.I18nBundle, ctx.Request.FormValue("lang"), ctx.GetHeader("Accept-Language"), "zh-CN")
+	localizer := i18n.NewLocalizer(svc.I18nBundle, ctx.Request.FormValue("lang"), ctx.GetHeader("Accept-Language"))
 
 	ctx.Set(Localizer, localizer)
 	ctx.Set(Language, ctx.GetHeader("Accept-Language"))
@@ -1,24 +1,102 @@
 package server
 
 import (
+	"crypto/tls"
 	"time"
 
 	grpc_ctxtags "github.com/grpc-ecosystem/go-grpc-middleware/tags"
+	grpc_opentracing "github.com/grpc-ecosystem/go-grpc-middleware/tracing/opentracing"
 	grpc_prometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/keepalive"
 )
 
+type (
+	grpcOptions struct {
+		unaryInterceptors  []grpc.UnaryServerInterceptor
+		streamInterceptors []grpc.StreamServerInterceptor
+		keepalive          bool
+		kaParams           *keepalive.ServerParameters
+		kaPolicy           *keepalive.EnforcementPolicy
+		maxRecvMsgSize     int
+		maxSendMsgSize     int
+		creds              credentials.TransportCredentials
+		serverOptions      []grpc.ServerOption
+	}
+
+	GrpcOption func(*grpcOptions)
+)
+
+// WithUnaryInterceptor appends interceptors after the default chain.
+func WithUnaryInterceptor(interceptors ...grpc.UnaryServerInterceptor) GrpcOption {
+	return func(o *grpcOptions) {
+		o.unaryInterceptors = append(o.unaryInterceptors, interceptors...)
+	}
+}
+
+// WithStreamInterceptor appends interceptors after the default chain.
+func WithStreamInterceptor(interceptors ...grpc.StreamServerInterceptor) GrpcOption {
+	return func(o *grpcOptions) {
+		o.streamInterceptors = append(o.streamInterceptors, interceptors...)
+	}
+}
+
+// WithKeepalive enables the default keepalive policy of ServerOpts.
+func WithKeepalive(enabled bool) GrpcOption {
+	return func(o *grpcOptions) {
+		o.keepalive = enabled
+	}
+}
+
+func WithKeepaliveParams(params keepalive.ServerParameters, policy keepalive.EnforcementPolicy) GrpcOption {
+	return func(o *grpcOptions) {
+		o.kaParams = &params
+		o.kaPolicy = &policy
+	}
+}
+
+func WithMaxMsgSize(recv, send int) GrpcOption {
+	return func(o *grpcOptions) {
+		o.maxRecvMsgSize = recv
+		o.maxSendMsgSize = send
+	}
+}
+
+func WithTLSConfig(c *tls.Config) GrpcOption {
+	return func(o *grpcOptions) {
+		o.creds = credentials.NewTLS(c)
+	}
+}
+
+func WithCredentials(creds credentials.TransportCredentials) GrpcOption {
+	return func(o *grpcOptions) {
+		o.creds = creds
+	}
+}
+
+func WithServerOption(opts ...grpc.ServerOption) GrpcOption {
+	return func(o *grpcOptions) {
+		o.serverOptions = append(o.serverOptions, opts...)
+	}
+}
+
+// ServerOpts returns the default interceptor chain, with panic recovery
+// outermost for both unary and stream calls, and the default keepalive
+// policy when kaEnabled.
 func ServerOpts(kaEnabled bool) []grpc.ServerOption {
 	opts := []grpc.ServerOption{
 		grpc.ChainUnaryInterceptor(
 			UnaryServerRecovery(),
 			grpc_ctxtags.UnaryServerInterceptor(),
+			grpc_opentracing.UnaryServerInterceptor(),
 			grpc_prometheus.UnaryServerInterceptor,
 		),
 
 		grpc.ChainStreamInterceptor(
+			StreamServerRecovery(),
 			grpc_ctxtags.StreamServerInterceptor(),
+			grpc_opentracing.StreamServerInterceptor(),
 			grpc_prometheus.StreamServerInterceptor,
 		),
 	}
@@ -39,3 +117,37 @@ func ServerOpts(kaEnabled bool) []grpc.ServerOption {
 
 	return opts
 }
+
+func (o *grpcOptions) build() []grpc.ServerOption {
+	opts := ServerOpts(o.keepalive)
+
+	if len(o.unaryInterceptors) > 0 {
+		opts = append(opts, grpc.ChainUnaryInterceptor(o.unaryInterceptors...))
+	}
+
+	if len(o.streamInterceptors) > 0 {
+		opts = append(opts, grpc.ChainStreamInterceptor(o.streamInterceptors...))
+	}
+
+	if o.kaParams != nil {
+		opts = append(opts, grpc.KeepaliveParams(*o.kaParams))
+	}
+
+	if o.kaPolicy != nil {
+		opts = append(opts, grpc.KeepaliveEnforcementPolicy(*o.kaPolicy))
+	}
+
+	if o.maxRecvMsgSize > 0 {
+		opts = append(opts, grpc.MaxRecvMsgSize(o.maxRecvMsgSize))
+	}
+
+	if o.maxSendMsgSize > 0 {
+		opts = append(opts, grpc.MaxSendMsgSize(o.maxSendMsgSize))
+	}
+
+	if o.creds != nil {
+		opts = append(opts, grpc.Creds(o.creds))
+	}
+
+	return append(opts, o.serverOptions...)
+}
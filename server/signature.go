@@ -0,0 +1,41 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopastro/go-common/common"
+	"github.com/shopastro/go-common/controller"
+	"github.com/shopastro/go-common/signature"
+	"github.com/shopastro/logs"
+	"go.uber.org/zap"
+)
+
+// SignatureKeyName holds the key id of a verified request in the gin context.
+const SignatureKeyName = "signatureKeyId"
+
+// SignatureHandler rejects requests without a valid signature from v,
+// see signature.Signer.
+func SignatureHandler(v *signature.Verifier) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		keyID, err := v.Verify(ctx.Request)
+		if err != nil {
+			logs.Logger.Error("[SignatureHandler]",
+				zap.String("uri", ctx.Request.RequestURI),
+				zap.String("keyId", keyID),
+				zap.Error(err))
+
+			ctx.Set(controller.DewuCode, http.StatusUnauthorized)
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, controller.Response{
+				TraceId: common.NewRequest().TraceId(ctx),
+				Code:    http.StatusUnauthorized,
+				Status:  http.StatusUnauthorized,
+				Msg:     http.StatusText(http.StatusUnauthorized),
+			})
+			return
+		}
+
+		ctx.Set(SignatureKeyName, keyID)
+		ctx.Next()
+	}
+}
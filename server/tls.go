@@ -0,0 +1,340 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopastro/logs"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+type (
+	TLSConfig struct {
+		CertFile string `json:"certFile" yaml:"certFile"`
+		KeyFile  string `json:"keyFile" yaml:"keyFile"`
+		// CAFile enables client certificate verification (mTLS); with
+		// ClientAuth false a client certificate is verified only if sent.
+		CAFile         string        `json:"caFile" yaml:"caFile"`
+		ClientAuth     bool          `json:"clientAuth" yaml:"clientAuth"`
+		ReloadInterval time.Duration `json:"reloadInterval" yaml:"reloadInterval"`
+		// ServerName is verified by the gateway when it dials the gRPC server,
+		// it defaults to the dialed host.
+		ServerName string `json:"serverName" yaml:"serverName"`
+		// ClientCertFile and ClientKeyFile are presented by the gateway for
+		// mTLS. They default to CertFile and KeyFile, which then need the
+		// client auth extended key usage.
+		ClientCertFile string `json:"clientCertFile" yaml:"clientCertFile"`
+		ClientKeyFile  string `json:"clientKeyFile" yaml:"clientKeyFile"`
+	}
+
+	ClientIdentity struct {
+		CommonName string   `json:"commonName"`
+		DNSNames   []string `json:"dnsNames"`
+		URIs       []string `json:"uris"`
+		Emails     []string `json:"emails"`
+		IPs        []string `json:"ips"`
+	}
+
+	clientIdentityKey struct{}
+
+	// certReloader serves the certificate and client CAs from disk and
+	// reloads them when the files change.
+	certReloader struct {
+		cfg       *TLSConfig
+		mu        sync.RWMutex
+		cert      *tls.Certificate
+		clientCAs *x509.CertPool
+		modTimes  map[string]time.Time
+		done      chan struct{}
+		once      sync.Once
+	}
+)
+
+const (
+	ClientIdentityName          = "ClientIdentity"
+	TLSReloadIntervalDefault    = 10 * time.Second
+	tlsMinVersion               = tls.VersionTLS12
+	errTLSConfigMissingKeyPairs = "tls: certFile and keyFile are required"
+)
+
+func newCertReloader(cfg *TLSConfig) (*certReloader, error) {
+	if cfg.CertFile == "" || cfg.KeyFile == "" {
+		return nil, errors.New(errTLSConfigMissingKeyPairs)
+	}
+
+	r := &certReloader{
+		cfg:      cfg,
+		modTimes: make(map[string]time.Time),
+		done:     make(chan struct{}),
+	}
+
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+
+	go r.watch()
+
+	return r, nil
+}
+
+func (r *certReloader) load() error {
+	cert, err := tls.LoadX509KeyPair(r.cfg.CertFile, r.cfg.KeyFile)
+	if err != nil {
+		return fmt.Errorf("tls: load key pair %s: %w", r.cfg.CertFile, err)
+	}
+
+	var pool *x509.CertPool
+	if r.cfg.CAFile != "" {
+		body, err := ioutil.ReadFile(r.cfg.CAFile)
+		if err != nil {
+			return fmt.Errorf("tls: read ca %s: %w", r.cfg.CAFile, err)
+		}
+
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(body) {
+			return fmt.Errorf("tls: no certificate found in ca %s", r.cfg.CAFile)
+		}
+	}
+
+	modTimes := make(map[string]time.Time)
+	for _, f := range r.files() {
+		if info, err := os.Stat(f); err == nil {
+			modTimes[f] = info.ModTime()
+		}
+	}
+
+	r.mu.Lock()
+	r.cert = &cert
+	r.clientCAs = pool
+	r.modTimes = modTimes
+	r.mu.Unlock()
+
+	return nil
+}
+
+func (r *certReloader) watch() {
+	interval := r.cfg.ReloadInterval * time.Millisecond
+	if interval <= 0 {
+		interval = TLSReloadIntervalDefault
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.done:
+			return
+		case <-ticker.C:
+			if !r.changed() {
+				continue
+			}
+
+			if err := r.load(); err != nil {
+				logs.Logger.Error("[TLS] reload certificate", zap.Error(err))
+				continue
+			}
+
+			logs.Logger.Info("[TLS] certificate reloaded", zap.String("cert", r.cfg.CertFile))
+		}
+	}
+}
+
+func (r *certReloader) changed() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, f := range r.files() {
+		info, err := os.Stat(f)
+		if err != nil {
+			continue
+		}
+
+		if !info.ModTime().Equal(r.modTimes[f]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *certReloader) files() []string {
+	files := []string{r.cfg.CertFile, r.cfg.KeyFile}
+	if r.cfg.CAFile != "" {
+		files = append(files, r.cfg.CAFile)
+	}
+
+	return files
+}
+
+func (r *certReloader) stop() {
+	r.once.Do(func() {
+		close(r.done)
+	})
+}
+
+func (r *certReloader) clientAuth() tls.ClientAuthType {
+	switch {
+	case r.cfg.CAFile == "":
+		return tls.NoClientCert
+	case r.cfg.ClientAuth:
+		return tls.RequireAndVerifyClientCert
+	default:
+		return tls.VerifyClientCertIfGiven
+	}
+}
+
+// tlsConfig returns a config that picks up the current certificate and
+// client CAs on every handshake.
+func (r *certReloader) tlsConfig(nextProtos ...string) *tls.Config {
+	getCertificate := func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+		r.mu.RLock()
+		defer r.mu.RUnlock()
+
+		return r.cert, nil
+	}
+
+	return &tls.Config{
+		MinVersion:     tlsMinVersion,
+		NextProtos:     nextProtos,
+		GetCertificate: getCertificate,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			r.mu.RLock()
+			defer r.mu.RUnlock()
+
+			return &tls.Config{
+				MinVersion:     tlsMinVersion,
+				NextProtos:     nextProtos,
+				GetCertificate: getCertificate,
+				ClientCAs:      r.clientCAs,
+				ClientAuth:     r.clientAuth(),
+			}, nil
+		},
+	}
+}
+
+func (svc *GinServer) httpTLS() *TLSConfig {
+	return svc.ServerCfg.TLS
+}
+
+// grpcTLS falls back to the HTTP settings when GrpcTLS is not set.
+func (svc *GinServer) grpcTLS() *TLSConfig {
+	if svc.ServerCfg.GrpcTLS != nil {
+		return svc.ServerCfg.GrpcTLS
+	}
+
+	return svc.ServerCfg.TLS
+}
+
+func (svc *GinServer) newTLSConfig(cfg *TLSConfig, nextProtos ...string) (*tls.Config, error) {
+	r, err := newCertReloader(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	svc.rw.Lock()
+	svc.reloaders = append(svc.reloaders, r)
+	svc.rw.Unlock()
+
+	return r.tlsConfig(nextProtos...), nil
+}
+
+func (svc *GinServer) stopReloaders() {
+	svc.rw.RLock()
+	defer svc.rw.RUnlock()
+
+	for _, r := range svc.reloaders {
+		r.stop()
+	}
+}
+
+// ClientIdentityHandler puts the verified client certificate identity into
+// the gin context under ClientIdentityName and into the request context.
+func ClientIdentityHandler(ctx *gin.Context) {
+	if ctx.Request.TLS != nil {
+		if id := clientIdentity(*ctx.Request.TLS); id != nil {
+			ctx.Set(ClientIdentityName, id)
+			ctx.Request = ctx.Request.WithContext(context.WithValue(ctx.Request.Context(), clientIdentityKey{}, id))
+		}
+	}
+
+	ctx.Next()
+}
+
+func ClientIdentityFromContext(ctx context.Context) (*ClientIdentity, bool) {
+	id, ok := ctx.Value(clientIdentityKey{}).(*ClientIdentity)
+	return id, ok
+}
+
+func UnaryServerIdentity() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(withPeerIdentity(ctx), req)
+	}
+}
+
+func StreamServerIdentity() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &identityStream{ServerStream: ss, ctx: withPeerIdentity(ss.Context())})
+	}
+}
+
+type identityStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *identityStream) Context() context.Context {
+	return s.ctx
+}
+
+func withPeerIdentity(ctx context.Context) context.Context {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ctx
+	}
+
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok {
+		return ctx
+	}
+
+	if id := clientIdentity(info.State); id != nil {
+		return context.WithValue(ctx, clientIdentityKey{}, id)
+	}
+
+	return ctx
+}
+
+func clientIdentity(state tls.ConnectionState) *ClientIdentity {
+	if len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return nil
+	}
+
+	cert := state.VerifiedChains[0][0]
+	id := &ClientIdentity{
+		CommonName: cert.Subject.CommonName,
+		DNSNames:   cert.DNSNames,
+		Emails:     cert.EmailAddresses,
+	}
+
+	for _, u := range cert.URIs {
+		id.URIs = append(id.URIs, u.String())
+	}
+
+	for _, ip := range cert.IPAddresses {
+		id.IPs = append(id.IPs, ip.String())
+	}
+
+	return id
+}
@@ -0,0 +1,49 @@
+package shutdown
+
+import (
+	"context"
+	"sync"
+)
+
+type (
+	Hook func(ctx context.Context) error
+
+	entry struct {
+		name string
+		fn   Hook
+	}
+)
+
+var (
+	mu    sync.Mutex
+	hooks []entry
+)
+
+// Register adds a component close hook, GinServer runs them on shutdown in
+// reverse order after its own hooks. Registering a name again replaces it.
+func Register(name string, fn Hook) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	for i := range hooks {
+		if hooks[i].name == name {
+			hooks[i].fn = fn
+			return
+		}
+	}
+
+	hooks = append(hooks, entry{name: name, fn: fn})
+}
+
+// Run calls every hook in reverse registration order, report gets the
+// result of each.
+func Run(ctx context.Context, report func(name string, err error)) {
+	mu.Lock()
+	list := make([]entry, len(hooks))
+	copy(list, hooks)
+	mu.Unlock()
+
+	for i := len(list) - 1; i >= 0; i-- {
+		report(list[i].name, list[i].fn(ctx))
+	}
+}
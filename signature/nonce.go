@@ -0,0 +1,73 @@
+package signature
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+type (
+	// NonceStore records nonces, Use reports false when nonce was already
+	// used within ttl.
+	NonceStore interface {
+		Use(ctx context.Context, nonce string, ttl time.Duration) (bool, error)
+	}
+
+	// MemoryNonceStore is per process. It does not stop replays against
+	// other instances of the same service, use RedisNonceStore there.
+	MemoryNonceStore struct {
+		mu      sync.Mutex
+		nonces  map[string]time.Time
+		sweepAt time.Time
+	}
+
+	RedisNonceStore struct {
+		client redis.Cmdable
+		prefix string
+	}
+)
+
+func NewMemoryNonceStore() *MemoryNonceStore {
+	return &MemoryNonceStore{nonces: make(map[string]time.Time)}
+}
+
+func (s *MemoryNonceStore) Use(_ context.Context, nonce string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.After(s.sweepAt) {
+		for n, expire := range s.nonces {
+			if now.After(expire) {
+				delete(s.nonces, n)
+			}
+		}
+
+		s.sweepAt = now.Add(ttl)
+	}
+
+	if expire, ok := s.nonces[nonce]; ok && now.Before(expire) {
+		return false, nil
+	}
+
+	s.nonces[nonce] = now.Add(ttl)
+
+	return true, nil
+}
+
+// NewRedisNonceStore shares nonces between instances, client may be a
+// single node or a cluster client.
+func NewRedisNonceStore(client redis.Cmdable, prefix string) *RedisNonceStore {
+	if prefix == "" {
+		prefix = "signature:nonce:"
+	}
+
+	return &RedisNonceStore{client: client, prefix: prefix}
+}
+
+func (s *RedisNonceStore) Use(ctx context.Context, nonce string, ttl time.Duration) (bool, error) {
+	return s.client.SetNX(ctx, s.prefix+nonce, 1, ttl).Result()
+}
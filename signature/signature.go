@@ -0,0 +1,300 @@
+package signature
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shopastro/go-common/gorequest"
+)
+
+type (
+	// Config keys are id => secret, KeyID picks the one used for signing so
+	// keys can be rotated by deploying the new key to every verifier first.
+	// MaxBodySize is in bytes.
+	Config struct {
+		KeyID       string            `json:"keyId" yaml:"keyId"`
+		Keys        map[string]string `json:"keys" yaml:"keys"`
+		MaxSkew     int               `json:"maxSkew" yaml:"maxSkew"`
+		NonceTTL    int               `json:"nonceTtl" yaml:"nonceTtl"`
+		MaxBodySize int64             `json:"maxBodySize" yaml:"maxBodySize"`
+	}
+
+	Signer struct {
+		keyID  string
+		secret []byte
+	}
+
+	Verifier struct {
+		mu          sync.RWMutex
+		keys        map[string][]byte
+		maxSkew     time.Duration
+		nonceTTL    time.Duration
+		maxBodySize int64
+		nonces      NonceStore
+	}
+
+	VerifierOption func(*Verifier)
+)
+
+const (
+	KeyHeader       = "X-Signature-Key"
+	TimestampHeader = "X-Signature-Timestamp"
+	NonceHeader     = "X-Signature-Nonce"
+	SignatureHeader = "X-Signature"
+
+	defaultMaxSkew     = 5 * time.Minute
+	defaultMaxBodySize = 10 << 20
+)
+
+var (
+	ErrMissingSignature = errors.New("signature: missing signature headers")
+	ErrUnknownKey       = errors.New("signature: unknown key id")
+	ErrExpired          = errors.New("signature: timestamp out of range")
+	ErrBadSignature     = errors.New("signature: signature mismatch")
+	ErrReplayed         = errors.New("signature: nonce already used")
+)
+
+func NewSigner(keyID, secret string) *Signer {
+	return &Signer{keyID: keyID, secret: []byte(secret)}
+}
+
+// Signer returns nil when KeyID is not one of Keys.
+func (cfg *Config) Signer() *Signer {
+	secret, ok := cfg.Keys[cfg.KeyID]
+	if !ok {
+		return nil
+	}
+
+	return NewSigner(cfg.KeyID, secret)
+}
+
+func (cfg *Config) Verifier(opts ...VerifierOption) *Verifier {
+	if cfg.MaxSkew > 0 {
+		opts = append([]VerifierOption{WithMaxSkew(time.Duration(cfg.MaxSkew) * time.Millisecond)}, opts...)
+	}
+
+	if cfg.NonceTTL > 0 {
+		opts = append([]VerifierOption{WithNonceTTL(time.Duration(cfg.NonceTTL) * time.Millisecond)}, opts...)
+	}
+
+	if cfg.MaxBodySize > 0 {
+		opts = append([]VerifierOption{WithMaxBodySize(cfg.MaxBodySize)}, opts...)
+	}
+
+	return NewVerifier(cfg.Keys, opts...)
+}
+
+// Sign sets the signature headers on req.
+func (s *Signer) Sign(req *http.Request) error {
+	body, err := readBody(req, 0)
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set(KeyHeader, s.keyID)
+	req.Header.Set(TimestampHeader, ts)
+	req.Header.Set(NonceHeader, hex.EncodeToString(nonce))
+	req.Header.Set(SignatureHeader, sign(s.secret, canonical(req, body, ts, req.Header.Get(NonceHeader))))
+
+	return nil
+}
+
+// Middleware signs every request, use it with gorequest.SuperAgent.Use or
+// client.Config.Signer.
+func (s *Signer) Middleware(next http.RoundTripper) http.RoundTripper {
+	return gorequest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		req = req.Clone(req.Context())
+		if err := s.Sign(req); err != nil {
+			return nil, err
+		}
+
+		return next.RoundTrip(req)
+	})
+}
+
+// NewVerifier keeps nonces in memory unless WithNonceStore is given.
+//
+// The memory store only knows the nonces this process has seen: with more
+// than one instance behind a load balancer a captured request can be replayed
+// against another instance until it expires. Such deployments must pass a
+// shared store, e.g. WithNonceStore(NewRedisNonceStore(client, "")).
+func NewVerifier(keys map[string]string, opts ...VerifierOption) *Verifier {
+	v := &Verifier{
+		keys:        make(map[string][]byte),
+		maxSkew:     defaultMaxSkew,
+		maxBodySize: defaultMaxBodySize,
+	}
+
+	for id, secret := range keys {
+		v.keys[id] = []byte(secret)
+	}
+
+	for _, opt := range opts {
+		opt(v)
+	}
+
+	if v.nonceTTL <= 0 {
+		v.nonceTTL = 2 * v.maxSkew
+	}
+
+	if v.nonces == nil {
+		v.nonces = NewMemoryNonceStore()
+	}
+
+	return v
+}
+
+func WithMaxSkew(d time.Duration) VerifierOption {
+	return func(v *Verifier) {
+		v.maxSkew = d
+	}
+}
+
+func WithNonceTTL(d time.Duration) VerifierOption {
+	return func(v *Verifier) {
+		v.nonceTTL = d
+	}
+}
+
+// WithMaxBodySize limits the body read to verify a request, 10MB by default.
+func WithMaxBodySize(n int64) VerifierOption {
+	return func(v *Verifier) {
+		v.maxBodySize = n
+	}
+}
+
+func WithNonceStore(store NonceStore) VerifierOption {
+	return func(v *Verifier) {
+		v.nonces = store
+	}
+}
+
+func (v *Verifier) SetKey(id, secret string) {
+	v.mu.Lock()
+	v.keys[id] = []byte(secret)
+	v.mu.Unlock()
+}
+
+func (v *Verifier) RemoveKey(id string) {
+	v.mu.Lock()
+	delete(v.keys, id)
+	v.mu.Unlock()
+}
+
+// Verify checks the signature of req and returns the key id it was signed with.
+func (v *Verifier) Verify(req *http.Request) (string, error) {
+	keyID := req.Header.Get(KeyHeader)
+	ts := req.Header.Get(TimestampHeader)
+	nonce := req.Header.Get(NonceHeader)
+	signature := req.Header.Get(SignatureHeader)
+	if keyID == "" || ts == "" || nonce == "" || signature == "" {
+		return "", ErrMissingSignature
+	}
+
+	v.mu.RLock()
+	secret, ok := v.keys[keyID]
+	v.mu.RUnlock()
+	if !ok {
+		return keyID, ErrUnknownKey
+	}
+
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return keyID, ErrExpired
+	}
+
+	if skew := time.Since(time.Unix(unix, 0)); skew > v.maxSkew || skew < -v.maxSkew {
+		return keyID, ErrExpired
+	}
+
+	body, err := readBody(req, v.maxBodySize)
+	if err != nil {
+		return keyID, err
+	}
+
+	expected := sign(secret, canonical(req, body, ts, nonce))
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return keyID, ErrBadSignature
+	}
+
+	fresh, err := v.nonces.Use(contextOf(req), keyID+":"+nonce, v.nonceTTL)
+	if err != nil {
+		return keyID, err
+	}
+
+	if !fresh {
+		return keyID, ErrReplayed
+	}
+
+	return keyID, nil
+}
+
+// canonical is method, path, sorted query, body sha256, timestamp and nonce
+// joined by newlines.
+func canonical(req *http.Request, body []byte, ts, nonce string) string {
+	sum := sha256.Sum256(body)
+
+	return strings.Join([]string{
+		strings.ToUpper(req.Method),
+		req.URL.EscapedPath(),
+		req.URL.Query().Encode(),
+		hex.EncodeToString(sum[:]),
+		ts,
+		nonce,
+	}, "\n")
+}
+
+func sign(secret []byte, s string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(s))
+
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// readBody buffers the body of req, at most limit bytes when limit > 0.
+func readBody(req *http.Request, limit int64) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+
+	src := req.Body
+	if limit > 0 {
+		src = http.MaxBytesReader(nil, src, limit)
+	}
+
+	body, err := ioutil.ReadAll(src)
+	src.Close()
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(body)), nil
+	}
+
+	return body, err
+}
+
+func contextOf(req *http.Request) context.Context {
+	if ctx := req.Context(); ctx != nil {
+		return ctx
+	}
+
+	return context.Background()
+}
@@ -0,0 +1,123 @@
+package signature
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func signedRequest(t *testing.T, s *Signer, body string) *http.Request {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/orders?b=2&a=1", strings.NewReader(body))
+	if err := s.Sign(req); err != nil {
+		t.Fatalf("Sign() = %v", err)
+	}
+
+	return req
+}
+
+func TestVerify(t *testing.T) {
+	keys := map[string]string{"k1": "secret-1", "k2": "secret-2"}
+
+	tests := []struct {
+		name   string
+		signer *Signer
+		body   string
+		tamper func(req *http.Request)
+		want   error
+	}{
+		{"valid", NewSigner("k1", "secret-1"), `{"id":1}`, nil, nil},
+		{"rotated key", NewSigner("k2", "secret-2"), `{"id":1}`, nil, nil},
+		{"empty body", NewSigner("k1", "secret-1"), "", nil, nil},
+		{"missing headers", NewSigner("k1", "secret-1"), "", func(req *http.Request) {
+			req.Header.Del(SignatureHeader)
+		}, ErrMissingSignature},
+		{"unknown key", NewSigner("k3", "secret-3"), "", nil, ErrUnknownKey},
+		{"wrong secret", NewSigner("k1", "other"), "", nil, ErrBadSignature},
+		{"tampered body", NewSigner("k1", "secret-1"), `{"id":1}`, func(req *http.Request) {
+			req.Body = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"id":2}`)).Body
+		}, ErrBadSignature},
+		{"tampered query", NewSigner("k1", "secret-1"), "", func(req *http.Request) {
+			req.URL.RawQuery = "a=1&b=3"
+		}, ErrBadSignature},
+		{"tampered method", NewSigner("k1", "secret-1"), "", func(req *http.Request) {
+			req.Method = http.MethodPut
+		}, ErrBadSignature},
+		{"expired", NewSigner("k1", "secret-1"), "", func(req *http.Request) {
+			req.Header.Set(TimestampHeader, strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10))
+		}, ErrExpired},
+		{"future", NewSigner("k1", "secret-1"), "", func(req *http.Request) {
+			req.Header.Set(TimestampHeader, strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+		}, ErrExpired},
+		{"bad timestamp", NewSigner("k1", "secret-1"), "", func(req *http.Request) {
+			req.Header.Set(TimestampHeader, "now")
+		}, ErrExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewVerifier(keys)
+			req := signedRequest(t, tt.signer, tt.body)
+			if tt.tamper != nil {
+				tt.tamper(req)
+			}
+
+			if _, err := v.Verify(req); err != tt.want {
+				t.Fatalf("Verify() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyReplay(t *testing.T) {
+	v := NewVerifier(map[string]string{"k1": "secret-1"})
+	req := signedRequest(t, NewSigner("k1", "secret-1"), `{"id":1}`)
+
+	replay := req.Clone(req.Context())
+	replay.Body, _ = req.GetBody()
+
+	if _, err := v.Verify(req); err != nil {
+		t.Fatalf("Verify() = %v", err)
+	}
+
+	if _, err := v.Verify(replay); err != ErrReplayed {
+		t.Fatalf("Verify() replay = %v, want %v", err, ErrReplayed)
+	}
+}
+
+func TestVerifyBodyLimit(t *testing.T) {
+	s := NewSigner("k1", "secret-1")
+	keys := map[string]string{"k1": "secret-1"}
+
+	tests := []struct {
+		name  string
+		limit int64
+		body  string
+		fails bool
+	}{
+		{"within limit", 8, "12345678", false},
+		{"over limit", 8, "123456789", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewVerifier(keys, WithMaxBodySize(tt.limit))
+			if _, err := v.Verify(signedRequest(t, s, tt.body)); (err != nil) != tt.fails {
+				t.Fatalf("Verify() = %v, fails %v", err, tt.fails)
+			}
+		})
+	}
+}
+
+func TestRemoveKey(t *testing.T) {
+	v := NewVerifier(map[string]string{"k1": "secret-1"})
+	v.RemoveKey("k1")
+
+	if _, err := v.Verify(signedRequest(t, NewSigner("k1", "secret-1"), "")); err != ErrUnknownKey {
+		t.Fatalf("Verify() = %v, want %v", err, ErrUnknownKey)
+	}
+}
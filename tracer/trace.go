@@ -1,11 +1,14 @@
 package tracer
 
 import (
+	"context"
 	"fmt"
+	"io"
 
 	"github.com/opentracing/opentracing-go"
 	"github.com/uber/jaeger-client-go"
 
+	"github.com/shopastro/go-common/shutdown"
 	"github.com/shopastro/logs"
 	"github.com/uber/jaeger-client-go/config"
 	"github.com/uber/jaeger-client-go/zipkin"
@@ -23,6 +26,7 @@ type (
 
 var (
 	tracerClient opentracing.Tracer
+	tracerCloser io.Closer
 )
 
 func GetTracerClient() opentracing.Tracer {
@@ -54,7 +58,7 @@ func NewTracer(cfg *TraceConfig) opentracing.Tracer {
 
 	propagator := zipkin.NewZipkinB3HTTPHeaderPropagator()
 
-	tracerClient, _, err = traceCfg.NewTracer(
+	tracerClient, tracerCloser, err = traceCfg.NewTracer(
 		config.Logger(jaeger.StdLogger),
 		config.Injector(opentracing.HTTPHeaders, propagator),
 		config.Extractor(opentracing.HTTPHeaders, propagator),
@@ -67,5 +71,15 @@ func NewTracer(cfg *TraceConfig) opentracing.Tracer {
 	}
 
 	opentracing.SetGlobalTracer(tracerClient)
+	shutdown.Register("tracer", func(context.Context) error { return Close() })
 	return tracerClient
 }
+
+// Close flushes buffered spans to the agent.
+func Close() error {
+	if tracerCloser == nil {
+		return nil
+	}
+
+	return tracerCloser.Close()
+}